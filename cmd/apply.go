@@ -2,11 +2,14 @@ package cmd
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"os/user"
+	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/a3chron/stellar/internal/api"
 	"github.com/a3chron/stellar/internal/cache"
@@ -14,11 +17,13 @@ import (
 	"github.com/a3chron/stellar/internal/symlink"
 	"github.com/a3chron/stellar/internal/theme"
 	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
 	"github.com/spf13/cobra"
 )
 
 var forceApply bool
 var updateTheme bool
+var stripCustomApply bool
 
 func getCurrentUsername() string {
 	currentUser, err := user.Current()
@@ -30,16 +35,109 @@ func getCurrentUsername() string {
 
 // promptConfirmation asks for user confirmation, defaults to No
 func promptConfirmation(prompt string) bool {
+	response := promptAnswer(prompt, "y/N")
+	return response == "y" || response == "yes"
+}
+
+// isInteractive reports whether stdin is a terminal, i.e. whether someone can answer prompts
+func isInteractive() bool {
+	fd := os.Stdin.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
+
+// promptAnswer asks a question and returns the lowercased answer, "" if nothing could be read
+func promptAnswer(prompt, choices string) string {
 	reader := bufio.NewReader(os.Stdin)
-	fmt.Printf("%s [y/N]: ", prompt)
+	fmt.Printf("%s [%s]: ", prompt, choices)
 
 	response, err := reader.ReadString('\n')
 	if err != nil {
-		return false
+		return ""
 	}
 
-	response = strings.ToLower(strings.TrimSpace(response))
-	return response == "y" || response == "yes"
+	return strings.ToLower(strings.TrimSpace(response))
+}
+
+// confirmCustomCommands shows every command t would make Starship run and asks the user to trust it.
+// With offerStrip, the user can also choose to use the theme without its custom modules.
+func confirmCustomCommands(client *api.Client, t *theme.Theme, commands []theme.Command, offerStrip bool) trustDecision {
+	color.Red("\nSECURITY WARNING ")
+	if len(commands) > 0 {
+		color.Yellow("%s contains %d command(s) that can execute arbitrary code.", t, len(commands))
+	} else {
+		color.Yellow("%s contains [custom] modules that can execute arbitrary shell code.", t)
+	}
+	color.Yellow("Commands run on your system every time Starship renders your prompt.")
+	fmt.Println()
+	printCommandReport(commands)
+	color.Cyan("You can also review the full config at:")
+	fmt.Printf("  %s/%s/%s\n", client.BaseURL(), t.Author, t.Name)
+	fmt.Println()
+
+	if !offerStrip {
+		if promptConfirmation("Do you trust this theme and want to apply it?") {
+			return trustGranted
+		}
+		return trustDenied
+	}
+
+	color.Cyan("Answer s to use the theme without its [custom] modules, everything else stays the same.")
+	switch promptAnswer("Do you trust this theme and want to apply it?", "y/N/s") {
+	case "y", "yes":
+		return trustGranted
+	case "s", "strip":
+		return trustStripped
+	default:
+		return trustDenied
+	}
+}
+
+// printCommandReport lists each command verbatim with the module it belongs to and what runs it
+func printCommandReport(commands []theme.Command) {
+	for _, c := range commands {
+		runner := "executed directly"
+		if c.Interpreter != "" {
+			runner = "runs in " + c.Interpreter
+		}
+
+		color.New(color.Bold).Printf("  [%s] %s", c.Module, c.Option)
+		if c.Position.Line > 0 {
+			fmt.Printf(" (line %d)", c.Position.Line)
+		}
+		fmt.Printf(", %s:\n", runner)
+
+		for _, line := range strings.Split(strings.TrimRight(escapeControlChars(c.Command), "\n"), "\n") {
+			fmt.Printf("    %s\n", line)
+		}
+		fmt.Println()
+	}
+}
+
+// escapeControlChars makes control characters visible, so a command cannot hide
+// parts of itself from the terminal (e.g. with carriage returns or ANSI escape codes)
+func escapeControlChars(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r != '\n' && (unicode.IsControl(r) || r == '\u2028' || r == '\u2029') {
+			b.WriteString(strings.Trim(strconv.QuoteRune(r), "'"))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// printValidationWarnings lists the non-fatal problems found in t's config
+func printValidationWarnings(t *theme.Theme, result theme.ValidationResult) {
+	warnings := result.Warnings()
+	if len(warnings) == 0 {
+		return
+	}
+
+	color.Yellow("%s has %d config warning(s):", t, len(warnings))
+	for _, w := range warnings {
+		fmt.Printf("  %s\n", w)
+	}
 }
 
 var applyCmd = &cobra.Command{
@@ -54,6 +152,9 @@ var applyCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		if err := checkThemePolicy(t); err != nil {
+			return err
+		}
 
 		// 2. Load config early to check download history
 		cfg, err := config.Load()
@@ -64,27 +165,54 @@ var applyCmd = &cobra.Command{
 		// Theme identifier without version for tracking (author/name)
 		themeID := fmt.Sprintf("%s/%s", t.Author, t.Name)
 
-		client := api.NewClient()
-		isLocalOnly := false
+		client := newClient()
+		if updateTheme {
+			// Always revalidate metadata with the hub when explicitly checking for updates
+			client.SetMetadataTTL(0)
+		}
+		// In offline mode, themes can only come from the local cache, and so does the backup of the user's own config
+		isLocalOnly := isOffline() || isLocalBackup(t)
 
 		// 3. Resolve version if not explicitly specified
-		if !t.VersionExplicit {
-			themeDir, _ := t.CacheDir()
-			localVer, localErr := theme.FindLatestLocalVersion(themeDir)
+		var hubInfo *api.ThemeInfo // Hub metadata the version was resolved with, if any
+		pinnedVersion, isPinned := cfg.PinnedVersion(themeID)
+		if !t.VersionExplicit && isPinned {
+			// Pinned themes always resolve to their pinned version and are never updated
+			if err := checkPinnedVersion(t, pinnedVersion); err != nil {
+				return err
+			}
+			if updateTheme {
+				color.HiBlack("%s is pinned to %s, skipping update check (stellar unpin %s to update)", themeID, pinnedVersion, themeID)
+			}
+			t.Version = pinnedVersion
+		} else if !t.VersionExplicit {
+			// Stay within the version range from the identifier or a previous apply, if any
+			constraint := themeConstraint(cfg, t)
+			localVer, localErr := resolveLocalVersion(cfg, t)
 			hasLocalCache := localErr == nil
 
 			// If we have a local cache and --update is not set, use local version
-			if hasLocalCache && !updateTheme {
+			if hasLocalCache && (!updateTheme || isLocalOnly) {
+				if updateTheme {
+					color.HiBlack("Offline mode, skipping update check")
+				}
 				t.Version = localVer
+			} else if isLocalOnly {
+				return fmt.Errorf("theme not found in local cache: %s/%s (offline mode, cannot download)", t.Author, t.Name)
 			} else {
 				// Check online for latest version (first download or --update)
+				var latest api.VersionInfo
 				info, err := client.GetThemeInfo(t.Author, t.Name)
-				if err == nil && len(info.Versions) > 0 {
-					// Online theme found - use latest version from API
-					latestVersion := info.Versions[0].Version
+				if err == nil {
+					latest, err = resolveHubVersion(info, constraint)
+				}
+				if err == nil {
+					// Online theme found - use latest (matching) version from API
+					hubInfo = info
+					latestVersion := latest.Version
 
 					// If updating and we have a newer version available
-					if hasLocalCache && updateTheme && latestVersion != localVer {
+					if hasLocalCache && updateTheme && theme.CompareVersions(latestVersion, localVer) > 0 {
 						color.Yellow("Update available: %s -> %s", localVer, latestVersion)
 					}
 
@@ -93,23 +221,45 @@ var applyCmd = &cobra.Command{
 					// Fallback to local cache
 					isLocalOnly = true
 					if !hasLocalCache {
-						return fmt.Errorf("theme not found: %s/%s (not available online and no local cache)", t.Author, t.Name)
+						if errors.Is(err, api.ErrNotFound) && constraint != nil {
+							return fmt.Errorf("no version of %s/%s matches %s (online or in local cache)", t.Author, t.Name, constraint)
+						}
+						if errors.Is(err, api.ErrNotFound) {
+							return fmt.Errorf("theme not found: %s/%s (not available online and no local cache)", t.Author, t.Name)
+						}
+						return fmt.Errorf("failed to resolve %s/%s (no local cache): %w", t.Author, t.Name, err)
 					}
 					t.Version = localVer
-					color.HiBlack("Theme not found online, using local cache")
+					color.HiBlack("%s, using local cache", describeHubError(err))
 				}
 			}
 		}
 
+		// Leave out [custom] modules if asked to now, or if the theme was applied that way before
+		stripCustom := cfg.StripsCustom(themeID)
+		if cmd.Flags().Changed("strip-custom") {
+			stripCustom = stripCustomApply
+		}
+
 		// 5. Check if cached, download if not
 		if !cache.ThemeExists(t) {
+			if isOffline() {
+				return fmt.Errorf("theme not found in local cache: %s (offline mode, cannot download)", t)
+			}
 			if isLocalOnly {
 				return fmt.Errorf("theme not found in local cache: %s", t)
 			}
 
 			color.Yellow("Downloading %s...", t)
 
-			content, err := client.FetchThemeConfig(t.Author, t.Name, t.Version)
+			info, version, err := hubVersionInfo(client, hubInfo, t)
+			if err != nil {
+				return fmt.Errorf("failed to download: %w", err)
+			}
+			reporter := newProgress(t.String())
+			client.SetProgress(reporter.Update)
+			content, err := client.FetchThemeConfig(t.Author, t.Name, version)
+			reporter.Finish()
 			if err != nil {
 				return fmt.Errorf("failed to download: %w", err)
 			}
@@ -122,21 +272,25 @@ var applyCmd = &cobra.Command{
 			if !validationResult.Valid {
 				return fmt.Errorf("invalid config: %w", validationResult.Error)
 			}
+			printValidationWarnings(t, validationResult)
+			if err := checkSignature(info, t, content); err != nil {
+				return err
+			}
+			if err := checkDownloadPolicy(content, validationResult, stripCustom); err != nil {
+				return err
+			}
 
-			// Check for custom commands and warn user
-			if validationResult.HasCustomCommands && !forceApply {
-				color.Red("\nSECURITY WARNING ")
-				color.Yellow("This theme contains [custom] commands that can execute arbitrary shell code.")
-				color.Yellow("Custom commands run on your system every time Starship renders your prompt.")
-				fmt.Println()
-				color.Cyan("Before proceeding, you should review the config at:")
-				fmt.Printf("  https://stellar-hub.vercel.app/%s/%s\n", t.Author, t.Name)
-				fmt.Println()
-
-				if !promptConfirmation("Do you trust this theme and want to apply it?") {
+			// Check for custom commands and warn user, unless they were approved before
+			if !stripCustom {
+				decision, err := confirmTrusted(client, t, content, validationResult.Commands, forceApply, true)
+				if err != nil {
+					return err
+				}
+				if decision == trustDenied {
 					color.Yellow("Aborted. Theme was not applied.")
 					return nil
 				}
+				stripCustom = decision == trustStripped
 			}
 
 			if err := cache.SaveTheme(t, content); err != nil {
@@ -155,6 +309,21 @@ var applyCmd = &cobra.Command{
 
 			// Mark theme as downloaded
 			cfg.MarkDownloaded(themeID)
+		} else if !stripCustom {
+			// The cached file may have changed since its commands were approved
+			themePath, err := t.CachePath()
+			if err != nil {
+				return err
+			}
+			decision, err := confirmTrustedFile(client, t, themePath, forceApply, true)
+			if err != nil {
+				return err
+			}
+			if decision == trustDenied {
+				color.Yellow("Aborted. Theme was not applied.")
+				return nil
+			}
+			stripCustom = decision == trustStripped
 		}
 
 		// 4. Get cached path
@@ -163,6 +332,25 @@ var applyCmd = &cobra.Command{
 			return err
 		}
 
+		if stripCustom {
+			// Derived from the cached version every time, so it follows upstream changes
+			themePath, err = cache.SaveStrippedVariant(t)
+			if err != nil {
+				return fmt.Errorf("failed to create variant without custom modules: %w", err)
+			}
+
+			// Other modules can still run commands, e.g. python_binary
+			decision, err := confirmTrustedFile(client, t, themePath, forceApply, false)
+			if err != nil {
+				return err
+			}
+			if decision == trustDenied {
+				color.Yellow("Aborted. Theme was not applied.")
+				return nil
+			}
+			color.HiBlack("Using %s without its [custom] modules", t)
+		}
+
 		// 5. Create symlink FIRST (before saving config)
 		// This ensures that if symlink fails, config remains unchanged
 		backupPath, err := symlink.CreateSymlink(themePath)
@@ -176,6 +364,16 @@ var applyCmd = &cobra.Command{
 		cfg.CurrentTheme = t.String()
 		cfg.CurrentPath = themePath
 
+		// Remember the choice, so re-applying or upgrading the theme keeps it
+		cfg.SetStripCustom(themeID, stripCustom)
+
+		// Remember the version range so later --update runs stay inside it
+		if t.Constraint != nil {
+			cfg.SetVersionConstraint(themeID, t.Constraint.String())
+		} else if t.VersionExplicit {
+			cfg.ClearVersionConstraint(themeID)
+		}
+
 		if err := cfg.Save(); err != nil {
 			// Symlink succeeded but config save failed
 			// This is less severe - theme is applied, but rollback info may be lost
@@ -197,4 +395,5 @@ var applyCmd = &cobra.Command{
 func init() {
 	applyCmd.Flags().BoolVarP(&forceApply, "force", "f", false, "Skip custom command warning and apply without confirmation")
 	applyCmd.Flags().BoolVarP(&updateTheme, "update", "u", false, "Check for and download newer version if available")
+	applyCmd.Flags().BoolVar(&stripCustomApply, "strip-custom", false, "Apply the theme without its [custom] modules (remembered, --strip-custom=false to undo)")
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/a3chron/stellar/internal/cache"
 	"github.com/a3chron/stellar/internal/config"
 	"github.com/a3chron/stellar/internal/symlink"
 	"github.com/fatih/color"
@@ -50,6 +51,9 @@ var currentCmd = &cobra.Command{
 		fmt.Println()
 		fmt.Printf("  Theme:  %s\n", cfg.CurrentTheme)
 		fmt.Printf("  Path:   %s\n", cfg.CurrentPath)
+		if cache.IsStrippedPath(cfg.CurrentPath) {
+			fmt.Println("  Applied without its [custom] modules")
+		}
 		fmt.Println()
 
 		// Show symlink info
@@ -1,10 +1,13 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/a3chron/stellar/internal/api"
+	"github.com/a3chron/stellar/internal/signature"
 	"github.com/a3chron/stellar/internal/theme"
+	"github.com/a3chron/stellar/internal/trust"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
@@ -24,12 +27,26 @@ var infoCmd = &cobra.Command{
 		}
 
 		// Fetch theme info from API
-		client := api.NewClient()
+		client := newClient()
 		info, err := client.GetThemeInfo(t.Author, t.Name)
 		if err != nil {
+			if errors.Is(err, api.ErrNotFound) {
+				return fmt.Errorf("theme not found: %s/%s", t.Author, t.Name)
+			}
+			if errors.Is(err, api.ErrOffline) {
+				return fmt.Errorf("no cached information for %s/%s (offline mode)", t.Author, t.Name)
+			}
 			return fmt.Errorf("failed to fetch theme info: %w", err)
 		}
 
+		if info.Stale {
+			if isOffline() {
+				color.HiBlack("Offline mode, showing cached information")
+			} else {
+				color.HiBlack("Could not reach the hub, showing cached information")
+			}
+		}
+
 		// Display theme information
 		color.Cyan("═══════════════════════════════════════")
 		color.Green("  %s", info.Name)
@@ -43,6 +60,21 @@ var infoCmd = &cobra.Command{
 			fmt.Printf("Description:  %s\n", info.Description)
 		}
 		fmt.Printf("Downloads:    %d\n", info.Downloads)
+		hubKey, trustedKey, err := signingKeys(info, t.Author)
+		if err != nil {
+			return err
+		}
+		if hubKey != nil {
+			fmt.Printf("Signing key:  %s", hubKey.KeyID())
+			switch {
+			case trustedKey == nil:
+				color.HiBlack(" (trusted on first download)")
+			case trustedKey.Equal(hubKey):
+				color.Green(" (trusted)")
+			default:
+				color.Red(" (CHANGED, trusted key is %s)", trustedKey.KeyID())
+			}
+		}
 		fmt.Println()
 
 		// Versions
@@ -52,7 +84,12 @@ var infoCmd = &cobra.Command{
 			if v.VersionNotes != "" {
 				fmt.Printf(" - %s", v.VersionNotes)
 			}
-			fmt.Println()
+			status := signatureStatus(v, hubKey, trustedKey)
+			if status == "signed" {
+				color.Green(" [%s]", status)
+			} else {
+				color.HiBlack(" [%s]", status)
+			}
 		}
 		fmt.Println()
 
@@ -73,3 +110,25 @@ var infoCmd = &cobra.Command{
 		return nil
 	},
 }
+
+// signingKeys returns the key the hub publishes for the author of info and the one in the keyring, nil if there is none.
+// The keyring is keyed by author as in the theme identifier, like checkSignature does, not by the display name of the hub.
+func signingKeys(info *api.ThemeInfo, author string) (hubKey, trustedKey *signature.PublicKey, err error) {
+	if info.Author.PublicKey != "" {
+		if hubKey, err = signature.ParsePublicKey(info.Author.PublicKey); err != nil {
+			return nil, nil, fmt.Errorf("invalid public key of %s: %w", author, err)
+		}
+	}
+
+	keyring, err := trust.LoadKeyring()
+	if err != nil {
+		return nil, nil, err
+	}
+	if known := keyring.Lookup(author); known != nil {
+		if trustedKey, err = signature.ParsePublicKey(known.PublicKey); err != nil {
+			return nil, nil, fmt.Errorf("invalid key of %s in keyring: %w", author, err)
+		}
+	}
+
+	return hubKey, trustedKey, nil
+}
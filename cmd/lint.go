@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/a3chron/stellar/internal/api"
+	"github.com/a3chron/stellar/internal/cache"
+	"github.com/a3chron/stellar/internal/config"
+	"github.com/a3chron/stellar/internal/theme"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var lintStrict bool
+
+var lintCmd = &cobra.Command{
+	Use:   "lint [file|author/theme[@version]]",
+	Short: "Check a Starship config for problems",
+	Long: `Check a Starship config file or theme for problems like invalid TOML, wrong option types,
+misspelled modules, broken format strings and invalid styles.
+
+Themes are taken from the local cache, or downloaded from the hub (without saving) if not cached.
+Exits with a non-zero status if errors are found (or warnings, with --strict), for use in CI.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, content, err := loadLintTarget(args[0])
+		if err != nil {
+			return err
+		}
+
+		result, err := theme.ValidateConfigContent(content)
+		if err != nil {
+			return fmt.Errorf("validation error: %w", err)
+		}
+
+		// Problems without a diagnostic (e.g. the size limit) are errors for the whole file
+		if !result.Valid && len(result.Diagnostics) == 0 {
+			result.Diagnostics = append(result.Diagnostics, theme.Diagnostic{
+				Severity: theme.SeverityError,
+				Message:  result.Error.Error(),
+			})
+		}
+
+		errorCount, warningCount := 0, 0
+		for _, d := range result.Diagnostics {
+			location := name
+			if d.Position.Line > 0 {
+				location = fmt.Sprintf("%s:%d:%d", name, d.Position.Line, d.Position.Column)
+			}
+
+			if d.Severity == theme.SeverityError {
+				errorCount++
+				fmt.Printf("%s: %s %s\n", location, color.RedString("error:"), d.Message)
+			} else {
+				warningCount++
+				fmt.Printf("%s: %s %s\n", location, color.YellowString("warning:"), d.Message)
+			}
+		}
+
+		if errorCount == 0 && warningCount == 0 {
+			color.Green("No problems found in %s", name)
+			return nil
+		}
+
+		fmt.Printf("\n%d error(s), %d warning(s)\n", errorCount, warningCount)
+		if errorCount > 0 || (lintStrict && warningCount > 0) {
+			return fmt.Errorf("lint failed for %s", name)
+		}
+		return nil
+	},
+}
+
+// loadLintTarget reads the config to lint, from a file or the theme cache/hub.
+// Returns a name for the config to use in diagnostics and its content.
+func loadLintTarget(target string) (string, string, error) {
+	// Anything that exists on disk is a file, so "./author/theme" can be linted too
+	if info, err := os.Stat(target); err == nil && !info.IsDir() {
+		data, err := os.ReadFile(target)
+		if err != nil {
+			return "", "", err
+		}
+		return target, string(data), nil
+	} else if strings.HasSuffix(target, ".toml") {
+		return "", "", fmt.Errorf("file not found: %s", target)
+	}
+
+	t, err := theme.ParseIdentifier(target)
+	if err != nil {
+		return "", "", err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return "", "", err
+	}
+
+	if !t.VersionExplicit {
+		if localVer, err := resolveLocalVersion(cfg, t); err == nil {
+			t.Version = localVer
+		}
+	}
+
+	// Prefer the cached copy, that is what Starship actually uses
+	if t.Version != "latest" && cache.ThemeExists(t) {
+		path, err := t.CachePath()
+		if err != nil {
+			return "", "", err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", "", err
+		}
+		return path, string(data), nil
+	}
+
+	if isOffline() {
+		return "", "", fmt.Errorf("theme not found in local cache: %s (offline mode, cannot download)", t)
+	}
+
+	client := newClient()
+	var hubInfo *api.ThemeInfo
+	if !t.VersionExplicit {
+		info, err := client.GetThemeInfo(t.Author, t.Name)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to resolve %s/%s: %s", t.Author, t.Name, describeHubError(err))
+		}
+		latest, err := resolveHubVersion(info, themeConstraint(cfg, t))
+		if err != nil {
+			return "", "", fmt.Errorf("failed to resolve %s/%s: %w", t.Author, t.Name, err)
+		}
+		t.Version = latest.Version
+		hubInfo = info
+	}
+
+	_, version, err := hubVersionInfo(client, hubInfo, t)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to download: %w", err)
+	}
+
+	color.HiBlack("Downloading %s (not cached)...", t)
+	reporter := newProgress(t.String())
+	client.SetProgress(reporter.Update)
+	content, err := client.FetchThemeConfig(t.Author, t.Name, version)
+	reporter.Finish()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to download: %w", err)
+	}
+	return t.String(), content, nil
+}
+
+func init() {
+	lintCmd.Flags().BoolVar(&lintStrict, "strict", false, "Also fail on warnings")
+}
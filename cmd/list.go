@@ -2,9 +2,11 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/a3chron/stellar/internal/cache"
 	"github.com/a3chron/stellar/internal/config"
+	"github.com/a3chron/stellar/internal/theme"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
@@ -34,14 +36,22 @@ var listCmd = &cobra.Command{
 
 		color.Cyan("Cached Themes (%d):\n", len(themes))
 
-		for _, theme := range themes {
+		for _, cached := range themes {
 			// Check if this is the current theme
-			isCurrent := theme == cfg.CurrentTheme
+			isCurrent := cached == cfg.CurrentTheme
+
+			// Check if this is the pinned version of its theme
+			pin := ""
+			if themeID, version, ok := strings.Cut(cached, "@"); ok {
+				if pinned, isPinned := cfg.PinnedVersion(themeID); isPinned && theme.CompareVersions(pinned, version) == 0 {
+					pin = " (pinned)"
+				}
+			}
 
 			if isCurrent {
-				color.Green("  ✳ %s (current)", theme)
+				color.Green("  ✳ %s (current)%s", cached, pin)
 			} else {
-				fmt.Printf("    %s\n", theme)
+				fmt.Printf("    %s%s\n", cached, pin)
 			}
 		}
 
@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/a3chron/stellar/internal/api"
+	"github.com/a3chron/stellar/internal/cache"
+	"github.com/a3chron/stellar/internal/config"
+	"github.com/a3chron/stellar/internal/theme"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	outdatedJSON bool
+	outdatedJobs int
+)
+
+// Status values for an outdated check
+const (
+	statusOutdated = "outdated"
+	statusUpToDate = "up-to-date"
+	statusMissing  = "not-on-hub"
+	statusError    = "error"
+)
+
+// outdatedEntry is one row of the outdated report
+type outdatedEntry struct {
+	Theme         string `json:"theme"`
+	LocalVersion  string `json:"local_version"`
+	HubVersion    string `json:"hub_version,omitempty"`    // Newest version within the constraint, what upgrade would install
+	Constraint    string `json:"constraint,omitempty"`     // Version range the theme was applied with
+	LatestVersion string `json:"latest_version,omitempty"` // Newest version on the hub, if it is outside the constraint
+	VersionNotes  string `json:"version_notes,omitempty"`
+	Status        string `json:"status"`
+	Pinned        bool   `json:"pinned,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// themeInfoResult is the outcome of fetching ThemeInfo for one author/theme
+type themeInfoResult struct {
+	ID   string
+	Info *api.ThemeInfo
+	Err  error
+}
+
+// cachedThemeIDs returns the unique author/theme pairs in the local cache, sorted
+func cachedThemeIDs() ([]*theme.Theme, error) {
+	cached, err := cache.ListCachedThemes()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var themes []*theme.Theme
+	for _, id := range cached {
+		t, err := theme.ParseIdentifier(id)
+		if err != nil {
+			continue
+		}
+
+		key := fmt.Sprintf("%s/%s", t.Author, t.Name)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		t.Version = "latest"
+		t.VersionExplicit = false
+		themes = append(themes, t)
+	}
+
+	sort.Slice(themes, func(i, j int) bool {
+		return themes[i].String() < themes[j].String()
+	})
+
+	return themes, nil
+}
+
+// parallel calls fn for every index in [0, count) using at most workers goroutines
+func parallel(count, workers int, fn func(i int)) {
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for range max(workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fn(i)
+			}
+		}()
+	}
+
+	for i := range count {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// fetchThemeInfos fetches ThemeInfo for every theme using at most workers concurrent requests.
+// Results are returned in the same order as themes.
+func fetchThemeInfos(client *api.Client, themes []*theme.Theme, workers int) []themeInfoResult {
+	results := make([]themeInfoResult, len(themes))
+
+	parallel(len(themes), workers, func(i int) {
+		t := themes[i]
+		info, err := client.GetThemeInfo(t.Author, t.Name)
+		results[i] = themeInfoResult{
+			ID:   fmt.Sprintf("%s/%s", t.Author, t.Name),
+			Info: info,
+			Err:  err,
+		}
+	})
+
+	return results
+}
+
+var outdatedCmd = &cobra.Command{
+	Use:   "outdated",
+	Short: "List cached themes that have newer versions on the hub",
+	Long: `Compare every cached theme against the stellar hub and list the ones that
+are behind, as well as themes that no longer exist on the hub.
+
+Use --json for machine readable output, e.g. in CI.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		themes, err := cachedThemeIDs()
+		if err != nil {
+			return fmt.Errorf("failed to list themes: %w", err)
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+
+		client := newClient()
+		results := fetchThemeInfos(client, themes, outdatedJobs)
+
+		var entries []outdatedEntry
+		for i, result := range results {
+			// Pinned themes are compared at their pinned version
+			localVer, err := resolveLocalVersion(cfg, themes[i])
+			if err != nil {
+				continue
+			}
+			_, pinned := cfg.PinnedVersion(result.ID)
+
+			entry := outdatedEntry{Theme: result.ID, LocalVersion: localVer, Pinned: pinned}
+
+			switch {
+			case errors.Is(result.Err, api.ErrNotFound):
+				entry.Status = statusMissing
+			case result.Err != nil:
+				entry.Status = statusError
+				entry.Error = result.Err.Error()
+			case len(result.Info.Versions) == 0:
+				entry.Status = statusMissing
+			default:
+				// Stay within the version range the theme was applied with, like upgrade does
+				constraint := themeConstraint(cfg, themes[i])
+				target, err := resolveHubVersion(result.Info, constraint)
+				if err != nil {
+					// Nothing on the hub matches the range, so there is nothing to upgrade to
+					entry.Status = statusUpToDate
+					break
+				}
+
+				entry.HubVersion = target.Version
+				if constraint != nil {
+					entry.Constraint = constraint.String()
+					if latest := result.Info.Versions[0].Version; latest != target.Version {
+						entry.LatestVersion = latest
+					}
+				}
+				if theme.CompareVersions(target.Version, localVer) > 0 {
+					entry.Status = statusOutdated
+					entry.VersionNotes = target.VersionNotes
+				} else {
+					entry.Status = statusUpToDate
+				}
+			}
+
+			if entry.Status != statusUpToDate {
+				entries = append(entries, entry)
+			}
+		}
+
+		if outdatedJSON {
+			if entries == nil {
+				entries = []outdatedEntry{}
+			}
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(entries)
+		}
+
+		if len(entries) == 0 {
+			color.Green("All cached themes are up to date")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		_, _ = fmt.Fprintln(w, "THEME\tLOCAL\tHUB\tNOTES")
+		for _, entry := range entries {
+			switch entry.Status {
+			case statusOutdated:
+				local := entry.LocalVersion
+				if entry.Pinned {
+					local += " (pinned)"
+				}
+				hub := entry.HubVersion
+				if entry.LatestVersion != "" {
+					hub += fmt.Sprintf(" (%s, latest %s)", entry.Constraint, entry.LatestVersion)
+				}
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", entry.Theme, local, hub, entry.VersionNotes)
+			case statusMissing:
+				_, _ = fmt.Fprintf(w, "%s\t%s\t-\t%s\n", entry.Theme, entry.LocalVersion, "no longer on the hub")
+			case statusError:
+				_, _ = fmt.Fprintf(w, "%s\t%s\t?\t%s\n", entry.Theme, entry.LocalVersion, entry.Error)
+			}
+		}
+		_ = w.Flush()
+
+		fmt.Println("\nUpgrade with: stellar upgrade <author/theme> or stellar upgrade --all")
+		return nil
+	},
+}
+
+func init() {
+	outdatedCmd.Flags().BoolVar(&outdatedJSON, "json", false, "Output as JSON")
+	outdatedCmd.Flags().IntVarP(&outdatedJobs, "jobs", "j", 4, "Number of concurrent requests to the hub")
+}
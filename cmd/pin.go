@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/a3chron/stellar/internal/config"
+	"github.com/a3chron/stellar/internal/theme"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var pinCmd = &cobra.Command{
+	Use:   "pin [author/theme@version]",
+	Short: "Pin a theme to a specific version",
+	Long: `Pin a theme to a specific version.
+
+Pinned themes are applied at their pinned version when no version is given,
+and are skipped by apply --update and upgrade.
+
+Without arguments, lists all pinned themes.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 0 {
+			if len(cfg.Pins) == 0 {
+				color.Yellow("No themes pinned")
+				return nil
+			}
+
+			ids := make([]string, 0, len(cfg.Pins))
+			for id := range cfg.Pins {
+				ids = append(ids, id)
+			}
+			sort.Strings(ids)
+
+			color.Cyan("Pinned Themes (%d):\n", len(ids))
+			for _, id := range ids {
+				fmt.Printf("    %s@%s\n", id, cfg.Pins[id])
+			}
+			return nil
+		}
+
+		t, err := theme.ParseIdentifier(args[0])
+		if err != nil {
+			return err
+		}
+		if !t.VersionExplicit || t.Version == "latest" {
+			return fmt.Errorf("a version is required to pin a theme (e.g. stellar pin %s/%s@1.2)", t.Author, t.Name)
+		}
+
+		themeID := fmt.Sprintf("%s/%s", t.Author, t.Name)
+		cfg.Pin(themeID, t.Version)
+		if err := cfg.Save(); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+
+		color.Green("Pinned %s", t)
+		return nil
+	},
+}
+
+var unpinCmd = &cobra.Command{
+	Use:   "unpin [author/theme]",
+	Short: "Remove the version pin of a theme",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		t, err := theme.ParseIdentifier(args[0])
+		if err != nil {
+			return err
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+
+		themeID := fmt.Sprintf("%s/%s", t.Author, t.Name)
+		if !cfg.Unpin(themeID) {
+			color.Yellow("Theme is not pinned: %s", themeID)
+			return nil
+		}
+
+		if err := cfg.Save(); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+
+		color.Green("Unpinned %s", themeID)
+		return nil
+	},
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/a3chron/stellar/internal/cache"
+	"github.com/a3chron/stellar/internal/policy"
+	"github.com/a3chron/stellar/internal/theme"
+)
+
+// orgPolicy is the policy in effect, loaded before any command runs
+var orgPolicy = &policy.Policy{}
+
+// blocked passes err on. For policy violations the command line itself was fine, so no usage is printed.
+func blocked(err error) error {
+	var violation *policy.Violation
+	if errors.As(err, &violation) {
+		rootCmd.SilenceUsage = true
+	}
+	return err
+}
+
+// backupVersion is the version the backup of the user's original starship.toml is stored as
+const backupVersion = "1.0"
+
+// isLocalBackup reports whether t is the backup of the user's own starship.toml, made on the first apply.
+// Only the backup in the cache counts, not a theme of a hub author that happens to have the same name.
+func isLocalBackup(t *theme.Theme) bool {
+	if t.Author != getCurrentUsername() || t.Name != "backup" {
+		return false
+	}
+	if t.VersionExplicit && theme.CompareVersions(t.Version, backupVersion) != 0 {
+		return false
+	}
+
+	backup := *t
+	backup.Version = backupVersion
+	return cache.ThemeExists(&backup)
+}
+
+// checkThemePolicy returns a policy.Violation if t may not be used.
+// The allow lists don't apply to the user's own backup, its content is still checked like any other config.
+func checkThemePolicy(t *theme.Theme) error {
+	if isLocalBackup(t) {
+		return blocked(orgPolicy.CheckOwnTheme(t.Author, t.Name))
+	}
+	return blocked(orgPolicy.CheckTheme(t.Author, t.Name))
+}
+
+// checkConfigPolicy returns a policy.Violation if a config with content and commands may not be used
+func checkConfigPolicy(content string, commands []theme.Command) error {
+	return blocked(orgPolicy.CheckConfig(content, commands))
+}
+
+// checkDownloadPolicy checks a downloaded config before it is saved to the cache.
+// With stripCustom, only the commands outside of [custom] modules count, as those are all that will run.
+func checkDownloadPolicy(content string, result theme.ValidationResult, stripCustom bool) error {
+	if stripCustom && hasCustomModules(result.Commands) {
+		stripped, err := theme.StripCustom(content)
+		if err != nil {
+			return err
+		}
+		if result, err = theme.ValidateConfigContent(stripped); err != nil {
+			return err
+		}
+	}
+
+	return checkConfigPolicy(content, result.Commands)
+}
@@ -2,6 +2,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -9,11 +10,14 @@ import (
 
 	"github.com/a3chron/stellar/internal/api"
 	"github.com/a3chron/stellar/internal/cache"
+	"github.com/a3chron/stellar/internal/config"
 	"github.com/a3chron/stellar/internal/theme"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
 
+var stripCustomPreview bool
+
 var previewCmd = &cobra.Command{
 	Use:   "preview [author/theme[@version]]",
 	Short: "Preview a theme in a new terminal window",
@@ -26,37 +30,77 @@ var previewCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		if err := checkThemePolicy(t); err != nil {
+			return err
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
 
-		client := api.NewClient()
+		client := newClient()
 
 		// Resolve version if not explicitly specified
+		var hubInfo *api.ThemeInfo // Hub metadata the version was resolved with, if any
 		if !t.VersionExplicit {
-			themeDir, _ := t.CacheDir()
-			localVer, localErr := theme.FindLatestLocalVersion(themeDir)
+			if pinned, ok := cfg.PinnedVersion(fmt.Sprintf("%s/%s", t.Author, t.Name)); ok {
+				if err := checkPinnedVersion(t, pinned); err != nil {
+					return err
+				}
+			}
+			localVer, localErr := resolveLocalVersion(cfg, t)
 			hasLocalCache := localErr == nil
 
 			// If we have a local cache, use it (preview doesn't need --update)
 			if hasLocalCache {
 				t.Version = localVer
+			} else if isOffline() {
+				return fmt.Errorf("theme not found in local cache: %s/%s (offline mode, cannot download)", t.Author, t.Name)
 			} else {
 				// No local cache - check online for latest version
+				var latest api.VersionInfo
 				info, err := client.GetThemeInfo(t.Author, t.Name)
-				if err == nil && len(info.Versions) > 0 {
-					// Online theme found - use latest version from API
-					t.Version = info.Versions[0].Version
-				} else {
+				if err == nil {
+					latest, err = resolveHubVersion(info, themeConstraint(cfg, t))
+				}
+				if err == nil {
+					// Online theme found - use latest (matching) version from API
+					hubInfo = info
+					t.Version = latest.Version
+				} else if errors.Is(err, api.ErrNotFound) && t.Constraint != nil {
+					return fmt.Errorf("no version of %s/%s matches %s (online or in local cache)", t.Author, t.Name, t.Constraint)
+				} else if errors.Is(err, api.ErrNotFound) {
 					// No online and no local
 					return fmt.Errorf("theme not found: %s/%s (not available online and no local cache)", t.Author, t.Name)
+				} else {
+					return fmt.Errorf("failed to resolve %s/%s (no local cache): %w", t.Author, t.Name, err)
 				}
 			}
 		}
 
+		// Same as apply: leave out [custom] modules if asked to, or if the theme is applied that way
+		stripCustom := cfg.StripsCustom(fmt.Sprintf("%s/%s", t.Author, t.Name))
+		if cmd.Flags().Changed("strip-custom") {
+			stripCustom = stripCustomPreview
+		}
+
 		if !cache.ThemeExists(t) {
+			if isOffline() {
+				return fmt.Errorf("theme not found in local cache: %s (offline mode, cannot download)", t)
+			}
 
 			color.Yellow("Downloading %s...", t)
-			content, err := client.FetchThemeConfig(t.Author, t.Name, t.Version)
+			info, version, err := hubVersionInfo(client, hubInfo, t)
 			if err != nil {
-				return err
+				return fmt.Errorf("failed to download: %w", err)
+			}
+			reporter := newProgress(t.String())
+			client.SetProgress(reporter.Update)
+			content, err := client.FetchThemeConfig(t.Author, t.Name, version)
+			reporter.Finish()
+			if err != nil {
+				return fmt.Errorf("failed to download: %w", err)
 			}
 			validationResult, err := theme.ValidateConfigContent(content)
 			if err != nil {
@@ -65,6 +109,13 @@ var previewCmd = &cobra.Command{
 			if !validationResult.Valid {
 				return validationResult.Error
 			}
+			printValidationWarnings(t, validationResult)
+			if err := checkSignature(info, t, content); err != nil {
+				return err
+			}
+			if err := checkDownloadPolicy(content, validationResult, stripCustom); err != nil {
+				return err
+			}
 			if err := cache.SaveTheme(t, content); err != nil {
 				return err
 			}
@@ -75,6 +126,36 @@ var previewCmd = &cobra.Command{
 			return err
 		}
 
+		// The preview runs Starship with the theme, so its commands need approval as well
+		if !stripCustom {
+			decision, err := confirmTrustedFile(client, t, themePath, false, true)
+			if err != nil {
+				return err
+			}
+			if decision == trustDenied {
+				color.Yellow("Aborted. Preview was not opened.")
+				return nil
+			}
+			stripCustom = decision == trustStripped
+		}
+
+		if stripCustom {
+			themePath, err = cache.SaveStrippedVariant(t)
+			if err != nil {
+				return fmt.Errorf("failed to create variant without custom modules: %w", err)
+			}
+
+			// Other modules can still run commands, e.g. python_binary
+			decision, err := confirmTrustedFile(client, t, themePath, false, false)
+			if err != nil {
+				return err
+			}
+			if decision == trustDenied {
+				color.Yellow("Aborted. Preview was not opened.")
+				return nil
+			}
+		}
+
 		// Spawn terminal
 		err = spawnTerminalWithEnv(themePath, t.String())
 		if err != nil {
@@ -253,3 +334,7 @@ func spawnLinuxTerminal(starshipConfig, themeName, shell string) error {
 
 	return fmt.Errorf("no supported terminal could be launched")
 }
+
+func init() {
+	previewCmd.Flags().BoolVar(&stripCustomPreview, "strip-custom", false, "Preview the theme without its [custom] modules")
+}
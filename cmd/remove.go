@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/a3chron/stellar/internal/cache"
 	"github.com/a3chron/stellar/internal/config"
 	"github.com/a3chron/stellar/internal/theme"
 	"github.com/fatih/color"
@@ -34,6 +35,9 @@ Use --force to remove the currently active theme.`,
 		if err != nil {
 			return err
 		}
+		if t.Constraint != nil {
+			return fmt.Errorf("version ranges are not supported by remove: %s", identifier)
+		}
 
 		// Load config to check if it's current
 		cfg, err := config.Load()
@@ -67,6 +71,9 @@ func removeAllVersions(t *theme.Theme, cfg *config.Config) error {
 	currentThemeInDir := false
 	if cfg.CurrentPath != "" {
 		currentDir := filepath.Dir(cfg.CurrentPath)
+		if cache.IsStrippedPath(cfg.CurrentPath) {
+			currentDir = filepath.Dir(currentDir)
+		}
 		if currentDir == themeDir {
 			currentThemeInDir = true
 		}
@@ -131,8 +138,8 @@ func removeSpecificVersion(t *theme.Theme, cfg *config.Config) error {
 		return nil
 	}
 
-	// Remove theme file
-	if err := os.Remove(themePath); err != nil {
+	// Remove theme file, its recorded hash and variant without custom modules
+	if err := cache.RemoveTheme(t); err != nil {
 		return fmt.Errorf("failed to remove theme: %w", err)
 	}
 
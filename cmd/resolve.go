@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/a3chron/stellar/internal/api"
+	"github.com/a3chron/stellar/internal/config"
+	"github.com/a3chron/stellar/internal/theme"
+)
+
+// themeConstraint returns the version range to resolve t within: the one in the identifier,
+// or the one recorded in config.json from an earlier apply. Returns nil if there is none.
+func themeConstraint(cfg *config.Config, t *theme.Theme) *theme.Constraint {
+	if t.Constraint != nil {
+		return t.Constraint
+	}
+	if t.VersionExplicit {
+		return nil
+	}
+
+	raw, ok := cfg.VersionConstraint(fmt.Sprintf("%s/%s", t.Author, t.Name))
+	if !ok {
+		return nil
+	}
+
+	constraint, err := theme.ParseConstraint(raw)
+	if err != nil {
+		log.Printf("warning: ignoring invalid version constraint %q in config.json: %v", raw, err)
+		return nil
+	}
+	return constraint
+}
+
+// checkPinnedVersion fails if the identifier of t asks for a version range that excludes the pinned version
+func checkPinnedVersion(t *theme.Theme, pinned string) error {
+	if t.Constraint == nil || t.Constraint.Matches(pinned) {
+		return nil
+	}
+	themeID := fmt.Sprintf("%s/%s", t.Author, t.Name)
+	return fmt.Errorf("%s is pinned to %s, which does not match %s (run `stellar unpin %s` to use another version)", themeID, pinned, t.Constraint, themeID)
+}
+
+// resolveLocalVersion returns the version of t to use from the local cache:
+// the pinned version if the theme is pinned, otherwise the newest cached version
+// within its version constraint
+func resolveLocalVersion(cfg *config.Config, t *theme.Theme) (string, error) {
+	if pinned, ok := cfg.PinnedVersion(fmt.Sprintf("%s/%s", t.Author, t.Name)); ok {
+		if err := checkPinnedVersion(t, pinned); err != nil {
+			return "", err
+		}
+		return pinned, nil
+	}
+
+	themeDir, err := t.CacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	if constraint := themeConstraint(cfg, t); constraint != nil {
+		return theme.FindLatestLocalMatch(themeDir, constraint)
+	}
+	return theme.FindLatestLocalVersion(themeDir)
+}
+
+// resolveHubVersion returns the newest version on the hub, or the newest one within
+// constraint if it is not nil. The hub lists versions newest first.
+func resolveHubVersion(info *api.ThemeInfo, constraint *theme.Constraint) (api.VersionInfo, error) {
+	if len(info.Versions) == 0 {
+		return api.VersionInfo{}, fmt.Errorf("%w: no versions published", api.ErrNotFound)
+	}
+
+	if constraint == nil {
+		return info.Versions[0], nil
+	}
+
+	versions := make([]string, len(info.Versions))
+	for i, v := range info.Versions {
+		versions[i] = v.Version
+	}
+
+	best, ok := constraint.Highest(versions)
+	if !ok {
+		return api.VersionInfo{}, fmt.Errorf("%w: no version matches %s", api.ErrNotFound, constraint)
+	}
+
+	for _, v := range info.Versions {
+		if v.Version == best {
+			return v, nil
+		}
+	}
+	return api.VersionInfo{}, fmt.Errorf("%w: no version matches %s", api.ErrNotFound, constraint)
+}
+
+// hubVersionInfo returns the hub metadata of t's version to download it with, from info if t was resolved
+// with it already (nil otherwise), so a download doesn't ask the hub for the same metadata again
+func hubVersionInfo(client *api.Client, info *api.ThemeInfo, t *theme.Theme) (*api.ThemeInfo, *api.VersionInfo, error) {
+	if info != nil {
+		if v := info.FindVersion(t.Version); v != nil {
+			return info, v, nil
+		}
+	}
+	return client.GetVersionInfo(t.Author, t.Name, t.Version)
+}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/a3chron/stellar/internal/api"
 	"github.com/a3chron/stellar/internal/cache"
 	"github.com/a3chron/stellar/internal/config"
 	"github.com/a3chron/stellar/internal/symlink"
@@ -33,19 +32,43 @@ var rollbackCmd = &cobra.Command{
 			return fmt.Errorf("previous theme path not found in config")
 		}
 
+		// Parse the theme identifier
+		t, err := theme.ParseIdentifier(cfg.PreviousTheme)
+		if err != nil {
+			return fmt.Errorf("failed to parse previous theme: %w", err)
+		}
+		if err := checkThemePolicy(t); err != nil {
+			return err
+		}
+
+		// The previous theme may have been applied without its custom modules,
+		// then the variant is derived from the cached theme again below
+		stripped := cache.IsStrippedPath(cfg.PreviousPath)
+		upstreamPath := cfg.PreviousPath
+		if stripped {
+			if upstreamPath, err = t.CachePath(); err != nil {
+				return err
+			}
+		}
+
 		// Check if previous theme file exists, re-download if missing
-		if _, err := os.Stat(cfg.PreviousPath); os.IsNotExist(err) {
+		if _, err := os.Stat(upstreamPath); os.IsNotExist(err) {
+			if isOffline() {
+				return fmt.Errorf("previous theme not found in local cache: %s (offline mode, cannot download)", cfg.PreviousTheme)
+			}
+
 			color.Yellow("Previous theme not in cache, downloading...")
 
-			// Parse the theme identifier
-			t, err := theme.ParseIdentifier(cfg.PreviousTheme)
+			// Download the theme
+			client := newClient()
+			info, version, err := client.GetVersionInfo(t.Author, t.Name, t.Version)
 			if err != nil {
-				return fmt.Errorf("failed to parse previous theme: %w", err)
+				return fmt.Errorf("failed to download previous theme: %w", err)
 			}
-
-			// Download the theme
-			client := api.NewClient()
-			content, err := client.FetchThemeConfig(t.Author, t.Name, t.Version)
+			reporter := newProgress(t.String())
+			client.SetProgress(reporter.Update)
+			content, err := client.FetchThemeConfig(t.Author, t.Name, version)
+			reporter.Finish()
 			if err != nil {
 				return fmt.Errorf("failed to download previous theme: %w", err)
 			}
@@ -58,12 +81,35 @@ var rollbackCmd = &cobra.Command{
 			if !validationResult.Valid {
 				return fmt.Errorf("invalid config: %w", validationResult.Error)
 			}
+			printValidationWarnings(t, validationResult)
+			if err := checkSignature(info, t, content); err != nil {
+				return err
+			}
+			if err := checkDownloadPolicy(content, validationResult, stripped); err != nil {
+				return err
+			}
 
 			if err := cache.SaveTheme(t, content); err != nil {
 				return fmt.Errorf("failed to save theme: %w", err)
 			}
 		}
 
+		if stripped {
+			if _, err := cache.SaveStrippedVariant(t); err != nil {
+				return fmt.Errorf("failed to create variant without custom modules: %w", err)
+			}
+		}
+
+		// The previous theme's commands need approval, its file may have changed since
+		decision, err := confirmTrustedFile(newClient(), t, cfg.PreviousPath, false, false)
+		if err != nil {
+			return err
+		}
+		if decision == trustDenied {
+			color.Yellow("Aborted. Rollback was not applied.")
+			return nil
+		}
+
 		// Capture values for swap
 		previousTheme := cfg.PreviousTheme
 		previousPath := cfg.PreviousPath
@@ -1,17 +1,79 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/a3chron/stellar/internal/api"
+	"github.com/a3chron/stellar/internal/config"
 	stellarinit "github.com/a3chron/stellar/internal/init"
+	"github.com/a3chron/stellar/internal/policy"
+	"github.com/a3chron/stellar/internal/progress"
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
 
+var (
+	hubURLFlag  string
+	offlineFlag bool
+	yesFlag     bool
+	noFlag      bool
+	quietFlag   bool
+	// allowUnsignedFlag accepts unsigned versions of authors with a trusted key, see checkSignature
+	allowUnsignedFlag bool
+)
+
+// Exit codes besides 1 for any other error
+const (
+	// ExitRefused is the exit code when a theme was refused without asking,
+	// e.g. because there is no terminal or custom_commands is set to deny
+	ExitRefused = 3
+	// ExitBlocked is the exit code when an action was blocked by a policy file
+	ExitBlocked = 4
+)
+
+// refusedError is returned when a theme was refused without asking the user
+type refusedError struct {
+	msg string
+}
+
+func (e *refusedError) Error() string {
+	return e.msg
+}
+
+// refusef creates a refusedError. The command line itself was fine, so no usage is printed for it.
+func refusef(format string, args ...interface{}) error {
+	rootCmd.SilenceUsage = true
+	return &refusedError{msg: fmt.Sprintf(format, args...)}
+}
+
 var rootCmd = &cobra.Command{
 	Use:   "stellar",
 	Short: "Starship theme manager",
 	Long:  `Stellar - Discover, preview, and apply Starship themes from the community`,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		// Initialize stellar directory structure before any command runs
-		return stellarinit.EnsureStellarDir()
+		if err := stellarinit.EnsureStellarDir(); err != nil {
+			return err
+		}
+
+		// Load the policy before any command touches the hub, the cache or the symlink
+		p, err := policy.Load()
+		if err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+		orgPolicy = p
+
+		startBackgroundUpdateCheck()
+		return nil
+	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		printUpdateNotice(cmd)
 	},
 	// Custom version template (will be set by SetVersionInfo)
 	Version: "dev",
@@ -21,7 +83,123 @@ func Execute() error {
 	return rootCmd.Execute()
 }
 
+// ExitCode returns the exit code for an error returned by Execute
+func ExitCode(err error) int {
+	var refused *refusedError
+	if errors.As(err, &refused) {
+		return ExitRefused
+	}
+	var violation *policy.Violation
+	if errors.As(err, &violation) {
+		return ExitBlocked
+	}
+	return 1
+}
+
+// hubURL resolves the stellar hub to talk to.
+// Priority: hub_url in a policy file, --hub flag, STELLAR_HUB_URL env var, hub_url in config.json, default hub.
+func hubURL() string {
+	if pinned := orgPolicy.HubURL(); pinned != "" {
+		return pinned
+	}
+
+	if hub := configuredHubURL(); hub != "" {
+		return hub
+	}
+
+	return api.BaseURL
+}
+
+// configuredHubURL returns the hub the user asked for, "" if none
+func configuredHubURL() string {
+	if hubURLFlag != "" {
+		return hubURLFlag
+	}
+
+	if env := os.Getenv("STELLAR_HUB_URL"); env != "" {
+		return env
+	}
+
+	if cfg, err := config.Load(); err == nil && cfg.HubURL != "" {
+		return cfg.HubURL
+	}
+
+	return ""
+}
+
+// isOffline reports whether network access is disabled via --offline or STELLAR_OFFLINE
+func isOffline() bool {
+	if offlineFlag {
+		return true
+	}
+
+	offline, _ := strconv.ParseBool(os.Getenv("STELLAR_OFFLINE"))
+	return offline
+}
+
+// hubWarningShown makes sure the warning about an overridden hub is printed once per run
+var hubWarningShown bool
+
+// newClient creates an API client for the configured hub.
+// A hub pinned by a policy file always wins, a different hub asked for by the user is ignored with a warning.
+func newClient() *api.Client {
+	if configured := configuredHubURL(); configured != "" && !hubWarningShown {
+		if err := orgPolicy.CheckHub(configured); err != nil {
+			fmt.Fprintln(os.Stderr, color.YellowString("Warning: ignoring %s, using %s instead: %v", configured, orgPolicy.HubURL(), err))
+			hubWarningShown = true
+		}
+	}
+
+	client := api.NewClient(hubURL())
+	client.SetOffline(isOffline())
+
+	if cfg, err := config.Load(); err == nil && cfg.MetadataTTL != "" {
+		ttl, err := time.ParseDuration(cfg.MetadataTTL)
+		if err != nil {
+			log.Printf("warning: invalid metadata_ttl %q in config.json: %v", cfg.MetadataTTL, err)
+		} else {
+			client.SetMetadataTTL(ttl)
+		}
+	}
+
+	return client
+}
+
+// newProgress returns a reporter for downloading label, nil (which reports nothing) with --quiet
+func newProgress(label string) *progress.Reporter {
+	if quietFlag {
+		return nil
+	}
+	return progress.New(label)
+}
+
+// describeHubError turns an api error into a short explanation for the user
+func describeHubError(err error) string {
+	switch {
+	case errors.Is(err, api.ErrOffline):
+		return "Offline mode"
+	case errors.Is(err, api.ErrNotFound):
+		return "Theme not found on the hub"
+	case errors.Is(err, api.ErrRateLimited):
+		return "Rate limited by the hub"
+	case errors.Is(err, api.ErrServer):
+		return "The hub returned a server error"
+	case errors.Is(err, api.ErrNetwork):
+		return "Could not reach the hub"
+	default:
+		return err.Error()
+	}
+}
+
 func init() {
+	rootCmd.PersistentFlags().StringVar(&hubURLFlag, "hub", "", "Stellar hub URL (overrides STELLAR_HUB_URL and config.json)")
+	rootCmd.PersistentFlags().BoolVar(&offlineFlag, "offline", false, "Never access the network, only use the local cache (or set STELLAR_OFFLINE=1)")
+	rootCmd.PersistentFlags().BoolVar(&yesFlag, "yes", false, "Use themes with commands that were not approved before, without asking")
+	rootCmd.PersistentFlags().BoolVar(&noFlag, "no", false, "Refuse themes with commands that were not approved before, without asking")
+	rootCmd.MarkFlagsMutuallyExclusive("yes", "no")
+	rootCmd.PersistentFlags().BoolVarP(&quietFlag, "quiet", "q", false, "Don't show download progress")
+	rootCmd.PersistentFlags().BoolVar(&allowUnsignedFlag, "allow-unsigned", false, "Use unsigned versions of authors whose signing key is trusted")
+
 	rootCmd.AddCommand(applyCmd)
 	rootCmd.AddCommand(previewCmd)
 	rootCmd.AddCommand(listCmd)
@@ -31,4 +209,12 @@ func init() {
 	rootCmd.AddCommand(rollbackCmd)
 	rootCmd.AddCommand(removeCmd)
 	rootCmd.AddCommand(updateCmd)
+	rootCmd.AddCommand(searchCmd)
+	rootCmd.AddCommand(outdatedCmd)
+	rootCmd.AddCommand(upgradeCmd)
+	rootCmd.AddCommand(pinCmd)
+	rootCmd.AddCommand(unpinCmd)
+	rootCmd.AddCommand(lintCmd)
+	rootCmd.AddCommand(trustCmd)
+	rootCmd.AddCommand(verifyCmd)
 }
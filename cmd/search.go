@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/a3chron/stellar/internal/api"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	searchColorScheme string
+	searchGroup       string
+	searchAuthor      string
+	searchSort        string
+	searchPage        int
+	searchLimit       int
+)
+
+var searchCmd = &cobra.Command{
+	Use:   "search [query]",
+	Short: "Search for themes on the stellar hub",
+	Long: `Search the stellar hub for themes by name or description.
+
+Results can be narrowed down with --color-scheme, --group and --author,
+and ordered with --sort downloads|updated.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		query := ""
+		if len(args) > 0 {
+			query = strings.TrimSpace(args[0])
+		}
+
+		if searchSort != "" && searchSort != "downloads" && searchSort != "updated" {
+			return fmt.Errorf("invalid sort: %s (expected downloads or updated)", searchSort)
+		}
+		if searchPage < 1 {
+			return fmt.Errorf("invalid page: %d (must be 1 or greater)", searchPage)
+		}
+
+		client := newClient()
+		result, err := client.SearchThemes(api.SearchOptions{
+			Query:       query,
+			ColorScheme: searchColorScheme,
+			Group:       searchGroup,
+			Author:      searchAuthor,
+			Sort:        searchSort,
+			Page:        searchPage,
+			Limit:       searchLimit,
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(result.Themes) == 0 {
+			color.Yellow("No themes found")
+			return nil
+		}
+
+		color.Cyan("Found %d theme(s) (page %d of %d):\n", result.Total, result.Page, max(result.TotalPages, 1))
+
+		for _, info := range result.Themes {
+			color.Green("  %s/%s", info.Author.Name, info.Slug)
+			if info.Description != "" {
+				fmt.Printf("    %s\n", info.Description)
+			}
+
+			colorScheme := "-"
+			if info.ColorScheme != nil && *info.ColorScheme != "" {
+				colorScheme = *info.ColorScheme
+			}
+			group := info.Group
+			if group == "" {
+				group = "-"
+			}
+			fmt.Printf("    Downloads: %d  Color scheme: %s  Group: %s\n", info.Downloads, colorScheme, group)
+			fmt.Println()
+		}
+
+		if result.Page < result.TotalPages {
+			fmt.Printf("More results available, use --page %d\n", result.Page+1)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	searchCmd.Flags().StringVar(&searchColorScheme, "color-scheme", "", "Filter by color scheme (e.g. dark, light)")
+	searchCmd.Flags().StringVar(&searchGroup, "group", "", "Filter by theme group")
+	searchCmd.Flags().StringVar(&searchAuthor, "author", "", "Filter by author")
+	searchCmd.Flags().StringVar(&searchSort, "sort", "", "Sort results by downloads or updated")
+	searchCmd.Flags().IntVar(&searchPage, "page", 1, "Page of results to show")
+	searchCmd.Flags().IntVar(&searchLimit, "limit", 20, "Number of results per page")
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/a3chron/stellar/internal/api"
+	"github.com/a3chron/stellar/internal/signature"
+	"github.com/a3chron/stellar/internal/theme"
+	"github.com/a3chron/stellar/internal/trust"
+	"github.com/fatih/color"
+)
+
+// checkSignature verifies a downloaded config of t against the signature listed in info, the hub metadata it was downloaded with.
+// The key of an author is trusted the first time one of their signatures verifies and kept in
+// the keyring. Signatures are then only checked against that key, so a hub that starts serving
+// a different key for the author, or stops sending signatures, is refused until the user forgets
+// the old key (or passes --allow-unsigned for a version that really is unsigned).
+func checkSignature(info *api.ThemeInfo, t *theme.Theme, content string) error {
+	keyring, err := trust.LoadKeyring()
+	if err != nil {
+		return err
+	}
+	known := keyring.Lookup(t.Author)
+
+	var hubKey *signature.PublicKey
+	if info.Author.PublicKey != "" {
+		if hubKey, err = signature.ParsePublicKey(info.Author.PublicKey); err != nil {
+			return fmt.Errorf("invalid public key of %s: %w", t.Author, err)
+		}
+	}
+
+	if known != nil && hubKey != nil && hubKey.String() != known.PublicKey {
+		printKeyChanged(t.Author, known.KeyID, hubKey.KeyID())
+		return refusef("the signing key of %s changed, refusing %s (run `stellar trust forget-key %s` if the author announced a new key)", t.Author, t, t.Author)
+	}
+
+	var sigText string
+	if v := info.FindVersion(t.Version); v != nil {
+		sigText = v.Signature
+	}
+	if sigText == "" {
+		if known == nil {
+			return nil
+		}
+		// A hub could drop signatures to get around verification, so this counts like a changed key
+		if !allowUnsignedFlag {
+			return refusef("%s is not signed, although %s signed their themes before (key %s), refusing it (use --allow-unsigned if this version was published unsigned)", t, t.Author, known.KeyID)
+		}
+		color.Yellow("Warning: %s is NOT signed, although %s signed their themes before (key %s)", t, t.Author, known.KeyID)
+		return nil
+	}
+
+	sig, err := signature.ParseSignature(sigText)
+	if err != nil {
+		return fmt.Errorf("invalid signature of %s: %w", t, err)
+	}
+
+	key := hubKey
+	if known != nil {
+		if key, err = signature.ParsePublicKey(known.PublicKey); err != nil {
+			return fmt.Errorf("invalid key of %s in keyring: %w", t.Author, err)
+		}
+	}
+	if key == nil {
+		color.Yellow("Warning: %s is signed, but the hub does not publish a key for %s to verify it with", t, t.Author)
+		return nil
+	}
+
+	if err := signature.Verify(key, []byte(content), sig); err != nil {
+		rootCmd.SilenceUsage = true
+		return fmt.Errorf("signature of %s does not verify: %w", t, err)
+	}
+
+	if known == nil {
+		keyring.Trust(t.Author, key.String(), key.KeyID())
+		if err := keyring.Save(); err != nil {
+			return fmt.Errorf("failed to save keyring: %w", err)
+		}
+		color.Cyan("Trusting signing key %s of %s from now on (first use)", key.KeyID(), t.Author)
+	}
+	color.HiBlack("Signature of %s verified (key %s)", t, key.KeyID())
+
+	return nil
+}
+
+// printKeyChanged warns that the hub serves a different key for author than the trusted one
+func printKeyChanged(author, trustedID, hubID string) {
+	red := color.New(color.FgRed, color.Bold)
+	red.Println("@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@")
+	red.Printf("@    WARNING: SIGNING KEY OF %s HAS CHANGED!\n", author)
+	red.Println("@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@")
+	fmt.Println("Someone could be impersonating the author, or the hub was compromised.")
+	fmt.Println("It is also possible that the author replaced their key.")
+	fmt.Printf("  trusted key: %s\n", trustedID)
+	fmt.Printf("  hub serves:  %s\n", hubID)
+}
+
+// signatureStatus describes whether version v is signed, by the trusted key if there is one, the hub's key otherwise.
+// The signature itself is only verified on download, when the content is known.
+func signatureStatus(v api.VersionInfo, hubKey, trusted *signature.PublicKey) string {
+	if v.Signature == "" {
+		return "unsigned"
+	}
+	sig, err := signature.ParseSignature(v.Signature)
+	if err != nil {
+		return "invalid signature"
+	}
+
+	key := trusted
+	if key == nil {
+		key = hubKey
+	}
+	if key == nil || sig.KeyID != key.ID {
+		return "signed with an unknown key"
+	}
+	return "signed"
+}
@@ -0,0 +1,280 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/a3chron/stellar/internal/api"
+	"github.com/a3chron/stellar/internal/config"
+	"github.com/a3chron/stellar/internal/theme"
+	"github.com/a3chron/stellar/internal/trust"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// trustDecision is the outcome of asking the user about the commands of a theme
+type trustDecision int
+
+const (
+	trustDenied trustDecision = iota
+	trustGranted
+	trustStripped // Use the theme without its [custom] modules instead
+)
+
+// confirmTrusted makes sure the commands of t were approved before the theme is used.
+// Themes whose commands were approved before pass silently. For the others, --yes / --no or the
+// custom_commands policy decide, otherwise the user is asked and the answer is recorded in the trust store.
+// force skips all of this without recording anything, but never the policy files.
+// With offerStrip, the theme may be used without its custom modules instead.
+// Themes refused without asking the user return a refusedError.
+func confirmTrusted(client *api.Client, t *theme.Theme, content string, commands []theme.Command, force, offerStrip bool) (trustDecision, error) {
+	if err := checkConfigPolicy(content, commands); err != nil {
+		return trustDenied, err
+	}
+
+	if len(commands) == 0 || force {
+		return trustGranted, nil
+	}
+	offerStrip = offerStrip && hasCustomModules(commands)
+
+	policy, source, err := customCommandsPolicy()
+	if err != nil {
+		return trustDenied, err
+	}
+
+	store, err := trust.Load()
+	if err != nil {
+		return trustDenied, err
+	}
+
+	themeID := fmt.Sprintf("%s/%s", t.Author, t.Name)
+	status := store.Check(themeID, t.Version, content, commands)
+	if status == trust.Trusted {
+		return trustGranted, nil
+	}
+
+	switch policy {
+	case config.CustomCommandsAllow:
+		return trustGranted, nil
+	case config.CustomCommandsDeny:
+		return trustDenied, refusef("%s runs %d command(s) that were not approved, refused by %s", t, len(commands), source)
+	case config.CustomCommandsStrip:
+		if !offerStrip {
+			return trustDenied, refusef("%s runs %d command(s) outside of [custom] modules that were not approved, refused by %s", t, len(commands), source)
+		}
+		color.HiBlack("%s runs commands that were not approved, leaving out its [custom] modules (%s)", t, source)
+		return trustStripped, nil
+	}
+
+	if !isInteractive() {
+		return trustDenied, refusef("%s runs %d command(s) that were not approved, and there is no terminal to ask (use --yes / --no, or set custom_commands in config.json)", t, len(commands))
+	}
+
+	if status == trust.Changed {
+		color.Yellow("\nThe commands in %s are different from the ones you approved before.", t)
+	}
+
+	decision := confirmCustomCommands(client, t, commands, offerStrip)
+	if decision != trustGranted {
+		return decision, nil
+	}
+
+	store.Approve(themeID, t.Version, content, commands)
+	if err := store.Save(); err != nil {
+		return trustDenied, fmt.Errorf("failed to save trust store: %w", err)
+	}
+	return trustGranted, nil
+}
+
+// customCommandsPolicy returns how to handle themes with unapproved commands, and where that was set.
+// Priority: --yes / --no, custom_commands in config.json, prompt.
+func customCommandsPolicy() (string, string, error) {
+	switch {
+	case yesFlag:
+		return config.CustomCommandsAllow, "--yes", nil
+	case noFlag:
+		return config.CustomCommandsDeny, "--no", nil
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return "", "", err
+	}
+	policy, err := cfg.CustomCommandsPolicy()
+	return policy, fmt.Sprintf("custom_commands = %s in config.json", policy), err
+}
+
+// confirmTrustedFile runs confirmTrusted for a config file already in the cache,
+// which might have changed or been cached before its commands were ever approved
+func confirmTrustedFile(client *api.Client, t *theme.Theme, path string, force, offerStrip bool) (trustDecision, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return trustDenied, err
+	}
+
+	// An invalid config is not blocked here, Starship will report it. It only matters what it would run.
+	result, err := theme.ValidateConfigContent(string(data))
+	if err != nil {
+		return trustDenied, err
+	}
+
+	return confirmTrusted(client, t, string(data), result.Commands, force, offerStrip)
+}
+
+// hasCustomModules reports whether any of the commands come from a [custom] module,
+// only then stripping custom modules makes a difference
+func hasCustomModules(commands []theme.Command) bool {
+	for _, c := range commands {
+		if strings.HasPrefix(c.Module, "custom.") {
+			return true
+		}
+	}
+	return false
+}
+
+var trustCmd = &cobra.Command{
+	Use:   "trust",
+	Short: "Manage approved themes and trusted signing keys",
+	Long: `Themes that run commands (e.g. [custom] modules) have to be approved before they are used.
+Approvals are stored with the SHA-256 of the theme and its commands, so you are asked again
+whenever the commands of a theme change.
+
+The signing key of an author is trusted the first time one of their signed themes is
+downloaded. Themes are refused if the hub later serves a different key for that author.`,
+}
+
+var trustListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List approved themes",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := trust.Load()
+		if err != nil {
+			return err
+		}
+
+		if len(store.Entries) == 0 {
+			color.Yellow("No approved themes")
+			return nil
+		}
+
+		entries := append([]trust.Entry(nil), store.Entries...)
+		sort.Slice(entries, func(i, j int) bool {
+			if entries[i].Theme != entries[j].Theme {
+				return entries[i].Theme < entries[j].Theme
+			}
+			return theme.CompareVersions(entries[i].Version, entries[j].Version) > 0
+		})
+
+		color.Cyan("Approved Themes (%d):\n", len(entries))
+		for _, e := range entries {
+			fmt.Printf("    %s@%s", e.Theme, e.Version)
+			color.HiBlack("  sha256:%s, approved %s", e.SHA256[:min(len(e.SHA256), 12)], e.ApprovedAt.Local().Format("2006-01-02"))
+			for _, c := range e.Commands {
+				lines := strings.Split(strings.TrimRight(escapeControlChars(c), "\n"), "\n")
+				fmt.Printf("      %s\n", strings.Join(lines, "\n        "))
+			}
+		}
+		return nil
+	},
+}
+
+var trustRevokeCmd = &cobra.Command{
+	Use:   "revoke [author/theme[@version]]",
+	Short: "Remove the approval of a theme, or of one version of it",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		t, err := theme.ParseIdentifier(args[0])
+		if err != nil {
+			return err
+		}
+		if t.Constraint != nil {
+			return fmt.Errorf("version ranges are not supported by revoke, use an exact version or none")
+		}
+
+		store, err := trust.Load()
+		if err != nil {
+			return err
+		}
+
+		version := ""
+		if t.VersionExplicit && t.Version != "latest" {
+			version = t.Version
+		}
+
+		themeID := fmt.Sprintf("%s/%s", t.Author, t.Name)
+		removed := store.Revoke(themeID, version)
+		if removed == 0 {
+			color.Yellow("No approval found for %s", args[0])
+			return nil
+		}
+
+		if err := store.Save(); err != nil {
+			return fmt.Errorf("failed to save trust store: %w", err)
+		}
+
+		color.Green("Revoked %d approval(s) of %s", removed, args[0])
+		return nil
+	},
+}
+
+var trustKeysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "List trusted signing keys of authors",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		keyring, err := trust.LoadKeyring()
+		if err != nil {
+			return err
+		}
+
+		if len(keyring.Keys) == 0 {
+			color.Yellow("No trusted signing keys")
+			return nil
+		}
+
+		keys := append([]trust.AuthorKey(nil), keyring.Keys...)
+		sort.Slice(keys, func(i, j int) bool { return keys[i].Author < keys[j].Author })
+
+		color.Cyan("Trusted Signing Keys (%d):\n", len(keys))
+		for _, k := range keys {
+			fmt.Printf("    %-20s %s", k.Author, k.KeyID)
+			color.HiBlack("  trusted %s", k.TrustedAt.Local().Format("2006-01-02"))
+			color.HiBlack("      %s", k.PublicKey)
+		}
+		return nil
+	},
+}
+
+var trustForgetKeyCmd = &cobra.Command{
+	Use:   "forget-key [author]",
+	Short: "Forget the signing key of an author, the next one they sign with is trusted again",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		keyring, err := trust.LoadKeyring()
+		if err != nil {
+			return err
+		}
+
+		if !keyring.Forget(args[0]) {
+			color.Yellow("No signing key trusted for %s", args[0])
+			return nil
+		}
+
+		if err := keyring.Save(); err != nil {
+			return fmt.Errorf("failed to save keyring: %w", err)
+		}
+
+		color.Green("Forgot the signing key of %s", args[0])
+		return nil
+	},
+}
+
+func init() {
+	trustCmd.AddCommand(trustListCmd)
+	trustCmd.AddCommand(trustRevokeCmd)
+	trustCmd.AddCommand(trustKeysCmd)
+	trustCmd.AddCommand(trustForgetKeyCmd)
+}
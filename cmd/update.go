@@ -6,43 +6,94 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 
+	"github.com/a3chron/stellar/internal/config"
+	"github.com/a3chron/stellar/internal/download"
+	"github.com/a3chron/stellar/internal/signature"
+	"github.com/a3chron/stellar/internal/theme"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
 
 const (
-	LatestReleaseURL = "https://github.com/a3chron/stellar/releases/latest/download"
+	ReleaseDownloadURL = "https://github.com/a3chron/stellar/releases/download"
 )
 
-// fetchChecksums downloads checksums.txt from GitHub releases
-func fetchChecksums() (string, error) {
-	checksumsURL := fmt.Sprintf("%s/checksums.txt", LatestReleaseURL)
+// Size limits for the self-updater, downloads are aborted as soon as they get larger
+const (
+	maxReleaseInfoSize = 1 << 20   // Release metadata from the GitHub API
+	maxChecksumsSize   = 64 << 10  // checksums.txt
+	maxSignatureSize   = 4 << 10   // checksums.txt.minisig
+	maxBinarySize      = 256 << 20 // The stellar binary
+)
+
+// updatePublicKey is the minisign key releases sign checksums.txt with, embedded at build time
+var updatePublicKey string
+
+// SetUpdatePublicKey is called from main with the key embedded at build time, "" if the build has none
+func SetUpdatePublicKey(key string) {
+	updatePublicKey = key
+}
 
-	resp, err := http.Get(checksumsURL)
+// fetchReleaseFile downloads a small file like checksums.txt from the GitHub release with the given tag
+func fetchReleaseFile(tag, name string, maxSize int64) (string, error) {
+	fileURL := releaseFileURL(tag, name)
+
+	resp, err := http.Get(fileURL)
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch checksums: %w", err)
+		return "", fmt.Errorf("failed to fetch %s: %w", name, err)
 	}
 	defer func() {
 		_ = resp.Body.Close()
 	}()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("checksums not available (status: %d)", resp.StatusCode)
+		return "", fmt.Errorf("%s not available (status: %d)", name, resp.StatusCode)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := download.Read(resp, download.Options{MaxSize: maxSize})
 	if err != nil {
-		return "", fmt.Errorf("failed to read checksums: %w", err)
+		return "", fmt.Errorf("failed to read %s: %w", name, err)
 	}
 
 	return string(body), nil
 }
 
+// verifyChecksumsSignature checks that checksums were signed with the key embedded in this build,
+// for the release tag. The tag is part of the signed trusted comment, so the signed checksums of
+// an older (maybe vulnerable) release cannot be passed off as the latest one.
+func verifyChecksumsSignature(checksums, sigText, tag string) error {
+	if updatePublicKey == "" {
+		return fmt.Errorf("this build of stellar has no key to verify updates with, reinstall it with install.sh or your package manager")
+	}
+
+	key, err := signature.ParsePublicKey(updatePublicKey)
+	if err != nil {
+		return fmt.Errorf("invalid update key in this build: %w", err)
+	}
+	sig, err := signature.ParseSignature(sigText)
+	if err != nil {
+		return fmt.Errorf("invalid signature of checksums: %w", err)
+	}
+
+	if err := signature.Verify(key, []byte(checksums), sig); err != nil {
+		return fmt.Errorf("signature verification of checksums failed: %w\n\nThe release may have been tampered with, nothing was installed", err)
+	}
+	if !slices.Contains(strings.Fields(sig.TrustedComment), tag) {
+		return fmt.Errorf("checksums are signed for %q, not for %s\n\nThe release may have been tampered with, nothing was installed", sig.TrustedComment, tag)
+	}
+
+	return nil
+}
+
 // parseChecksum extracts the SHA256 hash for a specific binary from checksums.txt
 // Format: "hash  filename" (two spaces between hash and filename, goreleaser standard)
 func parseChecksum(checksums, binaryName string) (string, error) {
@@ -114,115 +165,334 @@ func verifyChecksum(expected, actual, binaryName string) error {
 	return nil
 }
 
-var updateCmd = &cobra.Command{
-	Use:   "update",
-	Short: "Update stellar CLI to the latest version",
-	RunE: func(cmd *cobra.Command, args []string) error {
-		color.Yellow("Checking for updates...")
+var (
+	updateToVersion   string
+	updateChannelFlag string
+	updateRollback    bool
+)
 
-		// Check if update is available
-		updateAvailable, latestVersion, err := IsUpdateAvailable()
-		if err != nil {
-			return fmt.Errorf("failed to check for updates: %w", err)
-		}
+// releaseFileURL returns the download URL of a file attached to the release with the given tag
+func releaseFileURL(tag, name string) string {
+	return fmt.Sprintf("%s/%s/%s", ReleaseDownloadURL, url.PathEscape(tag), name)
+}
+
+// previousBinaryPath returns where the binary replaced by the last update is kept
+func previousBinaryPath(execPath string) string {
+	return execPath + ".previous"
+}
 
-		if !updateAvailable {
-			color.Green("You're already on the latest version (%s)", latestVersion)
-			return nil
+// copyExecutable copies the file at src to dst through a temp file in the same directory,
+// so dst is either replaced completely or left alone
+func copyExecutable(src, dst string) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = in.Close()
+	}()
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".stellar-copy-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(tmp.Name())
 		}
+	}()
 
-		color.Yellow("Updating to version %s...", latestVersion)
+	if _, err := io.Copy(tmp, in); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
 
-		// Construct binary name based on OS/arch
-		binary := fmt.Sprintf("stellar-%s-%s", runtime.GOOS, runtime.GOARCH)
-		if runtime.GOOS == "windows" {
-			return fmt.Errorf("why would you use windows? Anyways, stellar does not yet support windows, but support is planned")
+// updateTarget returns the release update should install, nil if there is nothing to do
+func updateTarget(channel string) (*GitHubRelease, error) {
+	if updateToVersion != "" {
+		tag := updateToVersion
+		if !strings.HasPrefix(tag, "v") {
+			tag = "v" + tag
+		}
+		if _, ok := theme.ParseVersion(tag); !ok {
+			return nil, fmt.Errorf("invalid version: %s (expected e.g. v1.4.0)", updateToVersion)
 		}
 
-		// Step 1: Fetch checksums.txt for verification
-		color.Yellow("Fetching checksums...")
-		checksums, err := fetchChecksums()
+		release, err := GetRelease(tag)
 		if err != nil {
-			return fmt.Errorf("failed to fetch checksums: %w", err)
+			return nil, err
 		}
 
-		// Step 2: Parse checksum for our binary
-		expectedHash, err := parseChecksum(checksums, binary)
-		if err != nil {
-			return fmt.Errorf("failed to parse checksums: %w", err)
+		switch cmp := theme.CompareVersions(release.TagName, versionInfo.version); {
+		case cmp == 0:
+			color.Green("You're already on version %s", release.TagName)
+			return nil, nil
+		case cmp < 0 && !IsDev():
+			color.Yellow("Downgrading from %s to %s", versionInfo.version, release.TagName)
 		}
+		return release, nil
+	}
 
-		// Step 3: Download the binary
-		color.Yellow("Downloading %s...", binary)
-		downloadURL := fmt.Sprintf("%s/%s", LatestReleaseURL, binary)
+	if IsDev() {
+		color.Green("You're running a development build, use --version to install a release")
+		return nil, nil
+	}
 
-		resp, err := http.Get(downloadURL)
-		if err != nil {
-			return fmt.Errorf("failed to download: %w", err)
+	release, err := GetLatestRelease(channel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for updates: %w", err)
+	}
+	if !isNewerVersion(release.TagName) {
+		if theme.CompareVersions(release.TagName, versionInfo.version) < 0 {
+			color.Green("You're on %s, newer than the latest %s release (%s)", versionInfo.version, channel, release.TagName)
+		} else {
+			color.Green("You're already on the latest %s version (%s)", channel, versionInfo.version)
 		}
-		defer func() {
-			_ = resp.Body.Close()
-		}()
+		return nil, nil
+	}
 
-		if resp.StatusCode != http.StatusOK {
-			return fmt.Errorf("download failed (status: %d)", resp.StatusCode)
-		}
+	return release, nil
+}
 
-		// Write to temporary file
-		tmpFile, err := os.CreateTemp("", "stellar-update-*")
-		if err != nil {
-			return err
-		}
-		tmpPath := tmpFile.Name()
+// installRelease downloads the binary of release, verifies it and replaces the running one with it.
+// The replaced binary is kept next to it, for update --rollback.
+func installRelease(release *GitHubRelease) error {
+	tag := release.TagName
 
-		// Cleanup helper - only call this in error paths before rename
-		cleanup := func() {
-			_ = os.Remove(tmpPath)
-		}
+	// Construct binary name based on OS/arch
+	binary := fmt.Sprintf("stellar-%s-%s", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		return fmt.Errorf("why would you use windows? Anyways, stellar does not yet support windows, but support is planned")
+	}
 
-		if _, err := io.Copy(tmpFile, resp.Body); err != nil {
-			cleanup()
-			return err
-		}
-		if err := tmpFile.Close(); err != nil {
-			cleanup()
-			return fmt.Errorf("failed to close temp file: %w", err)
+	// Step 1: Fetch checksums.txt and its signature
+	color.Yellow("Fetching checksums...")
+	checksums, err := fetchReleaseFile(tag, "checksums.txt", maxChecksumsSize)
+	if err != nil {
+		return fmt.Errorf("failed to fetch checksums: %w", err)
+	}
+	sigText, err := fetchReleaseFile(tag, "checksums.txt.minisig", maxSignatureSize)
+	if err != nil {
+		return fmt.Errorf("failed to fetch signature of checksums: %w", err)
+	}
+
+	// Step 2: Verify the signature, checksums.txt comes from the same place as the binary,
+	// so on its own it only catches broken downloads
+	if err := verifyChecksumsSignature(checksums, sigText, tag); err != nil {
+		return err
+	}
+	color.Green("Signature of checksums verified")
+
+	// Step 3: Parse checksum for our binary
+	expectedHash, err := parseChecksum(checksums, binary)
+	if err != nil {
+		return fmt.Errorf("failed to parse checksums: %w", err)
+	}
+
+	// Step 4: Download the binary, next to the current one so it can be renamed over it
+	execPath, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	color.Yellow("Downloading %s...", binary)
+	resp, err := http.Get(releaseFileURL(tag, binary))
+	if err != nil {
+		return fmt.Errorf("failed to download: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download failed (status: %d)", resp.StatusCode)
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(execPath), ".stellar-update-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmpFile.Name()
+
+	// Cleanup helper - only call this in error paths before rename
+	cleanup := func() {
+		_ = os.Remove(tmpPath)
+	}
+
+	reporter := newProgress(binary)
+	_, err = download.Copy(tmpFile, resp, download.Options{
+		MaxSize:  maxBinarySize,
+		Progress: reporter.Update,
+	})
+	reporter.Finish()
+	if err != nil {
+		_ = tmpFile.Close()
+		cleanup()
+		return fmt.Errorf("failed to download %s: %w", binary, err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		cleanup()
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+
+	// Step 5: Compute hash of downloaded file
+	color.Yellow("Verifying checksum...")
+	actualHash, err := computeFileHash(tmpPath)
+	if err != nil {
+		cleanup()
+		return fmt.Errorf("failed to compute checksum: %w", err)
+	}
+
+	// Step 6: Verify checksum matches
+	if err := verifyChecksum(expectedHash, actualHash, binary); err != nil {
+		cleanup()
+		return err
+	}
+	color.Green("Checksum verified successfully")
+
+	// Step 7: Keep the current binary, then replace it (only after checksum verified)
+	if err := os.Chmod(tmpPath, 0755); err != nil {
+		cleanup()
+		return err
+	}
+
+	if err := copyExecutable(execPath, previousBinaryPath(execPath)); err != nil {
+		cleanup()
+		return fmt.Errorf("failed to keep the current binary: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, execPath); err != nil {
+		cleanup()
+		return err
+	}
+
+	// No cleanup needed - temp file was successfully moved
+	cfg, err := config.Load()
+	if err == nil {
+		cfg.PreviousVersion = versionInfo.version
+		err = cfg.Save()
+	}
+	if err != nil {
+		log.Printf("failed to record previous version: %v", err)
+	}
+
+	color.Green("Successfully updated to version %s!", tag)
+	color.HiBlack("The previous version (%s) was kept, run `stellar update --rollback` to go back", versionInfo.version)
+	return nil
+}
+
+// rollbackBinary swaps the running binary with the one kept by the last update,
+// so a second rollback goes forward again
+func rollbackBinary() error {
+	execPath, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	previousPath := previousBinaryPath(execPath)
+
+	if _, err := os.Stat(previousPath); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("no previous version of stellar to roll back to (one is kept by stellar update)")
 		}
+		return err
+	}
 
-		// Step 4: Compute hash of downloaded file
-		color.Yellow("Verifying checksum...")
-		actualHash, err := computeFileHash(tmpPath)
-		if err != nil {
-			cleanup()
-			return fmt.Errorf("failed to compute checksum: %w", err)
+	// Copy the running binary aside first, the previous one is then renamed over it in one step
+	swapPath := previousPath + ".swap"
+	if err := copyExecutable(execPath, swapPath); err != nil {
+		return fmt.Errorf("failed to keep the current binary: %w", err)
+	}
+	if err := os.Rename(previousPath, execPath); err != nil {
+		_ = os.Remove(swapPath)
+		return err
+	}
+	if err := os.Rename(swapPath, previousPath); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	restored := cfg.PreviousVersion
+	if restored == "" {
+		restored = "the previous version"
+	}
+	cfg.PreviousVersion = versionInfo.version
+	if err := cfg.Save(); err != nil {
+		return err
+	}
+
+	color.Green("Rolled back from %s to %s", versionInfo.version, restored)
+	color.HiBlack("Run `stellar update --rollback` again to undo")
+	return nil
+}
+
+var updateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Update stellar CLI to the latest version",
+	Long: `Update stellar to the newest release of the configured channel, or to a given version.
+
+The channel is "stable" by default. With --channel prerelease, prereleases are installed too,
+the choice is remembered in config.json (update_channel). The replaced binary is kept,
+so --rollback can restore it.`,
+	Example: `  stellar update
+  stellar update --version v1.4.0
+  stellar update --channel prerelease
+  stellar update --rollback`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if updateRollback {
+			return rollbackBinary()
 		}
 
-		// Step 5: Verify checksum matches
-		if err := verifyChecksum(expectedHash, actualHash, binary); err != nil {
-			cleanup()
-			return err
+		if isOffline() {
+			return fmt.Errorf("cannot update stellar in offline mode")
 		}
-		color.Green("Checksum verified successfully")
 
-		// Step 6: Replace current binary (only after checksum verified)
-		execPath, err := os.Executable()
+		cfg, err := config.Load()
 		if err != nil {
-			cleanup()
 			return err
 		}
-
-		if err := os.Chmod(tmpPath, 0755); err != nil {
-			cleanup()
+		if cmd.Flags().Changed("channel") {
+			cfg.UpdateChannel = updateChannelFlag
+			if _, err := cfg.ReleaseChannel(); err != nil {
+				return fmt.Errorf("invalid channel %q (use stable or prerelease)", updateChannelFlag)
+			}
+			if err := cfg.Save(); err != nil {
+				return err
+			}
+		}
+		channel, err := cfg.ReleaseChannel()
+		if err != nil {
 			return err
 		}
 
-		if err := os.Rename(tmpPath, execPath); err != nil {
-			cleanup()
+		color.Yellow("Checking for updates...")
+
+		release, err := updateTarget(channel)
+		if err != nil || release == nil {
 			return err
 		}
 
-		// No cleanup needed - temp file was successfully moved
-		color.Green("Successfully updated to version %s!", latestVersion)
-		return nil
+		color.Yellow("Updating to version %s...", release.TagName)
+		return installRelease(release)
 	},
 }
+
+func init() {
+	updateCmd.Flags().StringVar(&updateToVersion, "version", "", "Install this version instead of the newest one, e.g. v1.4.0 (downgrades too)")
+	updateCmd.Flags().StringVar(&updateChannelFlag, "channel", "", "Release channel to update from: stable or prerelease (remembered)")
+	updateCmd.Flags().BoolVar(&updateRollback, "rollback", false, "Restore the binary replaced by the last update")
+	updateCmd.MarkFlagsMutuallyExclusive("rollback", "version")
+	updateCmd.MarkFlagsMutuallyExclusive("rollback", "channel")
+}
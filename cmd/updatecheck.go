@@ -0,0 +1,239 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/a3chron/stellar/internal/config"
+	stellarinit "github.com/a3chron/stellar/internal/init"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// updateCheckTTL is how long a cached update check result is trusted
+	updateCheckTTL = 24 * time.Hour
+	// updateNoticeInterval is how often the "new version available" notice is shown
+	updateNoticeInterval = 24 * time.Hour
+)
+
+// savedUpdateCheck is the update check result saved by an earlier run, loaded before the background check starts
+var savedUpdateCheck *updateCheckState
+
+// updateCheckState is the cached result of the last update check
+type updateCheckState struct {
+	CheckedAt     time.Time `json:"checked_at"`
+	LatestVersion string    `json:"latest_version"`
+	PublishedAt   time.Time `json:"published_at"`
+	HTMLURL       string    `json:"html_url"`
+	Channel       string    `json:"channel,omitempty"` // Release channel the check was made for
+	NotifiedAt    time.Time `json:"notified_at,omitempty"`
+	LastError     string    `json:"last_error,omitempty"` // Why the last check failed, the other fields are from the last successful one
+}
+
+func updateCheckPath() (string, error) {
+	dir, err := stellarinit.StellarDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, ".cache", "update-check.json"), nil
+}
+
+// loadUpdateCheck returns the cached update check result, or nil if there is none
+func loadUpdateCheck() *updateCheckState {
+	path, err := updateCheckPath()
+	if err != nil {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var state updateCheckState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil
+	}
+
+	return &state
+}
+
+func (s *updateCheckState) save() error {
+	path, err := updateCheckPath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temp file of our own first, another stellar process may be saving at the same time
+	tmp, err := os.CreateTemp(filepath.Dir(path), "update-check-*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// isFresh reports whether the result is recent enough, and for the channel that is configured now
+func (s *updateCheckState) isFresh() bool {
+	channel, err := updateChannel()
+	if err != nil || s.channel() != channel {
+		return false
+	}
+	return time.Since(s.CheckedAt) < updateCheckTTL
+}
+
+// channel returns the release channel of the check, results from before channels were stable
+func (s *updateCheckState) channel() string {
+	if s.Channel == "" {
+		return config.UpdateChannelStable
+	}
+	return s.Channel
+}
+
+// hasUpdate reports whether the cached latest release is newer than the running version
+func (s *updateCheckState) hasUpdate() bool {
+	return s.LatestVersion != "" && isNewerVersion(s.LatestVersion)
+}
+
+// updateChannel returns the release channel configured in config.json
+func updateChannel() (string, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return "", err
+	}
+	return cfg.ReleaseChannel()
+}
+
+// updateChecksEnabled reports whether stellar may check GitHub for new releases
+func updateChecksEnabled() bool {
+	if IsDev() || isOffline() {
+		return false
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return true
+	}
+	return cfg.UpdateChecksEnabled()
+}
+
+// refreshUpdateCheck fetches the latest release and caches the result
+func refreshUpdateCheck() (*updateCheckState, error) {
+	channel, err := updateChannel()
+	if err != nil {
+		return nil, err
+	}
+
+	release, err := GetLatestRelease(channel)
+	if err != nil {
+		recordFailedUpdateCheck(channel, err)
+		return nil, err
+	}
+
+	state := &updateCheckState{
+		CheckedAt:     time.Now(),
+		LatestVersion: release.TagName,
+		PublishedAt:   release.PublishedAt,
+		HTMLURL:       release.HTMLURL,
+		Channel:       channel,
+	}
+
+	// Keep track of when we last nagged the user
+	if previous := loadUpdateCheck(); previous != nil && previous.LatestVersion == state.LatestVersion {
+		state.NotifiedAt = previous.NotifiedAt
+	}
+
+	_ = state.save()
+	return state, nil
+}
+
+// recordFailedUpdateCheck saves when a check failed, so a GitHub outage costs one attempt per updateCheckTTL
+// instead of one per command. The result of the last successful check for the channel is kept.
+func recordFailedUpdateCheck(channel string, err error) {
+	state := &updateCheckState{}
+	if previous := loadUpdateCheck(); previous != nil && previous.channel() == channel {
+		state = previous
+	}
+
+	state.CheckedAt = time.Now()
+	state.Channel = channel
+	state.LastError = err.Error()
+	_ = state.save()
+}
+
+// startBackgroundUpdateCheck refreshes the cached update check in a goroutine if it expired.
+// It never blocks the command; if stellar exits first, the next run simply tries again.
+func startBackgroundUpdateCheck() {
+	if !updateChecksEnabled() {
+		return
+	}
+
+	savedUpdateCheck = loadUpdateCheck()
+	if savedUpdateCheck != nil && savedUpdateCheck.isFresh() {
+		return
+	}
+
+	go func() {
+		_, _ = refreshUpdateCheck()
+	}()
+}
+
+// printUpdateNotice prints a one-line notice if a check of an earlier run found a newer release,
+// at most once per updateNoticeInterval. It doesn't wait for the background check of this run.
+func printUpdateNotice(cmd *cobra.Command) {
+	// These commands already report on updates themselves
+	if cmd == versionCmd || cmd == updateCmd {
+		return
+	}
+
+	if !updateChecksEnabled() {
+		return
+	}
+
+	state := savedUpdateCheck
+	if state == nil || !state.hasUpdate() {
+		return
+	}
+
+	if time.Since(state.NotifiedAt) < updateNoticeInterval {
+		return
+	}
+
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, color.YellowString("A new version of stellar is available: %s (current: %s), run: stellar update", state.LatestVersion, versionInfo.version))
+
+	// The background check may have saved a newer result in the meantime, only add the notice time to it
+	if current := loadUpdateCheck(); current != nil && current.LatestVersion == state.LatestVersion {
+		current.NotifiedAt = time.Now()
+		_ = current.save()
+	}
+}
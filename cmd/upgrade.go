@@ -0,0 +1,304 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/a3chron/stellar/internal/api"
+	"github.com/a3chron/stellar/internal/cache"
+	"github.com/a3chron/stellar/internal/config"
+	"github.com/a3chron/stellar/internal/policy"
+	"github.com/a3chron/stellar/internal/symlink"
+	"github.com/a3chron/stellar/internal/theme"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	upgradeAll    bool
+	upgradeDryRun bool
+	upgradeForce  bool
+	upgradeJobs   int
+)
+
+// upgradeItem is a cached theme with a newer version available on the hub
+type upgradeItem struct {
+	Theme        *theme.Theme // Points at the new version
+	LocalVersion string
+	Info         *api.ThemeInfo  // Hub metadata the new version was resolved with
+	Version      api.VersionInfo // The new version as listed in Info
+	Content      string
+	Err          error
+}
+
+// resolveUpgradeTargets returns the themes named in args, or every cached theme with --all
+func resolveUpgradeTargets(args []string) ([]*theme.Theme, error) {
+	if upgradeAll {
+		if len(args) > 0 {
+			return nil, fmt.Errorf("cannot combine --all with theme arguments")
+		}
+		return cachedThemeIDs()
+	}
+
+	if len(args) == 0 {
+		return nil, fmt.Errorf("specify themes to upgrade, or use --all")
+	}
+
+	var themes []*theme.Theme
+	for _, arg := range args {
+		t, err := theme.ParseIdentifier(arg)
+		if err != nil {
+			return nil, err
+		}
+		if t.VersionExplicit {
+			return nil, fmt.Errorf("cannot upgrade to a specific version: %s (use stellar apply instead)", arg)
+		}
+		themes = append(themes, t)
+	}
+
+	return themes, nil
+}
+
+var upgradeCmd = &cobra.Command{
+	Use:   "upgrade [author/theme...]",
+	Short: "Download newer versions of cached themes",
+	Long: `Check the stellar hub for newer versions of cached themes and download them.
+
+Pass one or more themes, or use --all to upgrade every cached theme.
+If the currently applied theme is upgraded, it is switched to the new version.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if isOffline() {
+			return fmt.Errorf("cannot upgrade themes in offline mode")
+		}
+
+		themes, err := resolveUpgradeTargets(args)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+
+		// Pinned themes are never upgraded, blocked ones are not touched at all
+		unpinned := themes[:0]
+		for _, t := range themes {
+			themeID := fmt.Sprintf("%s/%s", t.Author, t.Name)
+			if pinned, ok := cfg.PinnedVersion(themeID); ok {
+				color.HiBlack("  %s is pinned to %s, skipping", themeID, pinned)
+				continue
+			}
+			if err := checkThemePolicy(t); err != nil {
+				color.Yellow("  %v, skipping", err)
+				continue
+			}
+			unpinned = append(unpinned, t)
+		}
+		themes = unpinned
+
+		client := newClient()
+		client.SetMetadataTTL(0)
+
+		// 1. Find themes with a newer version on the hub
+		var items []*upgradeItem
+		for i, result := range fetchThemeInfos(client, themes, upgradeJobs) {
+			themeDir, err := themes[i].CacheDir()
+			if err != nil {
+				return err
+			}
+			localVer, err := theme.FindLatestLocalVersion(themeDir)
+			if err != nil {
+				color.Yellow("  %s is not cached, skipping (use stellar apply)", result.ID)
+				continue
+			}
+
+			if result.Err != nil {
+				if !errors.Is(result.Err, api.ErrNotFound) || !upgradeAll {
+					color.Yellow("  %s: %s", result.ID, describeHubError(result.Err))
+				}
+				continue
+			}
+
+			// Stay within the version range the theme was applied with
+			latestInfo, err := resolveHubVersion(result.Info, themeConstraint(cfg, themes[i]))
+			if err != nil {
+				continue
+			}
+
+			latest := latestInfo.Version
+			if theme.CompareVersions(latest, localVer) <= 0 {
+				continue
+			}
+
+			items = append(items, &upgradeItem{
+				Theme: &theme.Theme{
+					Author:          themes[i].Author,
+					Name:            themes[i].Name,
+					Version:         latest,
+					VersionExplicit: true,
+				},
+				LocalVersion: localVer,
+				Info:         result.Info,
+				Version:      latestInfo,
+			})
+		}
+
+		if len(items) == 0 {
+			color.Green("All themes are up to date")
+			return nil
+		}
+
+		if upgradeDryRun {
+			color.Cyan("Would upgrade %d theme(s):\n", len(items))
+			for _, item := range items {
+				fmt.Printf("  %s/%s: %s -> %s\n", item.Theme.Author, item.Theme.Name, item.LocalVersion, item.Theme.Version)
+			}
+			return nil
+		}
+
+		// 2. Download all new versions in parallel
+		color.Yellow("Downloading %d theme(s)...", len(items))
+		parallel(len(items), upgradeJobs, func(i int) {
+			item := items[i]
+			item.Content, item.Err = client.FetchThemeConfig(item.Theme.Author, item.Theme.Name, &item.Version)
+		})
+
+		// 3. Validate, confirm and save one after another, so prompts don't interleave
+		current, _ := theme.ParseIdentifier(cfg.CurrentTheme)
+		var upgradedCurrent *theme.Theme
+		upgraded := 0
+		refused := false // Whether a theme was refused without asking
+		var violation *policy.Violation
+
+		for _, item := range items {
+			t := item.Theme
+			if item.Err != nil {
+				color.Red("  %s: failed to download: %v", t, item.Err)
+				continue
+			}
+
+			validationResult, err := theme.ValidateConfigContent(item.Content)
+			if err != nil {
+				color.Red("  %s: validation error: %v", t, err)
+				continue
+			}
+			if !validationResult.Valid {
+				color.Red("  %s: invalid config: %v", t, validationResult.Error)
+				continue
+			}
+			printValidationWarnings(t, validationResult)
+			if err := checkSignature(item.Info, t, item.Content); err != nil {
+				var refusal *refusedError
+				if errors.As(err, &refusal) {
+					color.Yellow("  Skipped %s: %v", t, err)
+					refused = true
+				} else {
+					color.Red("  %s: %v", t, err)
+				}
+				continue
+			}
+
+			// Themes applied without their custom modules only run the remaining commands
+			stripCustom := cfg.StripsCustom(fmt.Sprintf("%s/%s", t.Author, t.Name))
+			content, commands := item.Content, validationResult.Commands
+			if stripCustom {
+				stripped, err := theme.StripCustom(item.Content)
+				if err != nil {
+					color.Red("  %s: %v", t, err)
+					continue
+				}
+				strippedResult, err := theme.ValidateConfigContent(stripped)
+				if err != nil {
+					color.Red("  %s: validation error: %v", t, err)
+					continue
+				}
+				content, commands = stripped, strippedResult.Commands
+			}
+
+			// Only ask if the new version runs commands that were not approved before
+			decision, err := confirmTrusted(client, t, content, commands, upgradeForce, false)
+			var refusal *refusedError
+			if errors.As(err, &refusal) {
+				color.Yellow("  Skipped %s: %v", t, err)
+				refused = true
+				continue
+			}
+			if errors.As(err, &violation) {
+				color.Yellow("  Skipped %s: %v", t, err)
+				continue
+			}
+			if err != nil {
+				color.Red("  %s: %v", t, err)
+				continue
+			}
+			if decision == trustDenied {
+				color.Yellow("  Skipped %s", t)
+				continue
+			}
+
+			if err := cache.SaveTheme(t, item.Content); err != nil {
+				color.Red("  %s: failed to save: %v", t, err)
+				continue
+			}
+			if stripCustom {
+				if _, err := cache.SaveStrippedVariant(t); err != nil {
+					color.Red("  %s: failed to create variant without custom modules: %v", t, err)
+					continue
+				}
+			}
+
+			color.Green("  Upgraded %s/%s: %s -> %s", t.Author, t.Name, item.LocalVersion, t.Version)
+			upgraded++
+
+			if current != nil && current.Author == t.Author && current.Name == t.Name {
+				upgradedCurrent = t
+			}
+		}
+
+		// 4. Switch the applied theme to its new version
+		if upgradedCurrent != nil {
+			themePath, err := upgradedCurrent.CachePath()
+			if cfg.StripsCustom(fmt.Sprintf("%s/%s", upgradedCurrent.Author, upgradedCurrent.Name)) {
+				themePath, err = cache.StrippedPath(upgradedCurrent)
+			}
+			if err != nil {
+				return err
+			}
+
+			if _, err := symlink.CreateSymlink(themePath); err != nil {
+				return fmt.Errorf("failed to update symlink: %w", err)
+			}
+
+			cfg.PreviousTheme = cfg.CurrentTheme
+			cfg.PreviousPath = cfg.CurrentPath
+			cfg.CurrentTheme = upgradedCurrent.String()
+			cfg.CurrentPath = themePath
+
+			if err := cfg.Save(); err != nil {
+				return fmt.Errorf("theme upgraded but failed to save config: %w", err)
+			}
+
+			color.Green("\nApplied %s", upgradedCurrent)
+		}
+
+		if violation != nil {
+			return fmt.Errorf("upgraded %d of %d theme(s), last one blocked: %w", upgraded, len(items), violation)
+		}
+		if refused {
+			return refusef("upgraded %d of %d theme(s)", upgraded, len(items))
+		}
+		if upgraded < len(items) {
+			return fmt.Errorf("upgraded %d of %d theme(s)", upgraded, len(items))
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	upgradeCmd.Flags().BoolVar(&upgradeAll, "all", false, "Upgrade all cached themes")
+	upgradeCmd.Flags().BoolVar(&upgradeDryRun, "dry-run", false, "Show what would be upgraded without downloading")
+	upgradeCmd.Flags().BoolVarP(&upgradeForce, "force", "f", false, "Skip custom command warning and upgrade without confirmation")
+	upgradeCmd.Flags().IntVarP(&upgradeJobs, "jobs", "j", 4, "Number of concurrent requests to the hub")
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/a3chron/stellar/internal/cache"
+	"github.com/a3chron/stellar/internal/theme"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// verifyTargets returns the cached theme versions named in args, or all of them if args is empty
+func verifyTargets(args []string) ([]*theme.Theme, error) {
+	var filters []*theme.Theme
+	for _, arg := range args {
+		t, err := theme.ParseIdentifier(arg)
+		if err != nil {
+			return nil, err
+		}
+		if t.Constraint != nil {
+			return nil, fmt.Errorf("version ranges are not supported by verify, use an exact version or none")
+		}
+		filters = append(filters, t)
+	}
+
+	cached, err := cache.ListCachedThemes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list themes: %w", err)
+	}
+
+	var themes []*theme.Theme
+	for _, id := range cached {
+		t, err := theme.ParseIdentifier(id)
+		if err != nil {
+			continue
+		}
+
+		matches := len(filters) == 0
+		for _, f := range filters {
+			if f.Author == t.Author && f.Name == t.Name && (!f.VersionExplicit || theme.CompareVersions(f.Version, t.Version) == 0) {
+				matches = true
+				break
+			}
+		}
+		if matches {
+			themes = append(themes, t)
+		}
+	}
+
+	sort.Slice(themes, func(i, j int) bool {
+		if themes[i].Author != themes[j].Author || themes[i].Name != themes[j].Name {
+			return themes[i].String() < themes[j].String()
+		}
+		return theme.CompareVersions(themes[i].Version, themes[j].Version) > 0
+	})
+
+	return themes, nil
+}
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify [author/theme[@version]...]",
+	Short: "Check cached themes against the hash recorded when they were downloaded",
+	Long: `Compare every cached theme version (or the ones given) with the SHA-256 recorded
+when it was downloaded, and report the ones that were edited locally or tampered with.
+
+Downloads are checked against the SHA-256 the hub lists for a version, so a theme
+that passes verify still matches what the hub served.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		themes, err := verifyTargets(args)
+		if err != nil {
+			return err
+		}
+
+		if len(themes) == 0 {
+			color.Yellow("No cached themes to verify")
+			return nil
+		}
+
+		ok, unrecorded, failed := 0, 0, 0
+		var modified []*theme.Theme
+		for _, t := range themes {
+			expected, actual, err := cache.VerifyTheme(t)
+			switch {
+			case err != nil:
+				color.Red("  %-10s %s: %v", "error", t, err)
+				failed++
+			case expected == "":
+				color.HiBlack("  %-10s %s (no hash recorded, e.g. local or cached by an older stellar)", "unknown", t)
+				unrecorded++
+			case expected != actual:
+				color.Red("  %-10s %s", "MODIFIED", t)
+				color.HiBlack("             expected sha256 %s", expected)
+				color.HiBlack("             found    sha256 %s", actual)
+				modified = append(modified, t)
+			default:
+				fmt.Printf("  %-10s %s\n", "ok", t)
+				ok++
+			}
+		}
+
+		fmt.Printf("\nVerified %d theme version(s): %d ok, %d modified, %d without hash\n", len(themes), ok, len(modified), unrecorded)
+
+		if len(modified) > 0 {
+			color.Cyan("\nRe-download a modified theme with:")
+			fmt.Printf("  stellar remove %s --force && stellar apply %s\n", modified[0], modified[0])
+			return fmt.Errorf("%d cached theme version(s) do not match their recorded hash", len(modified))
+		}
+		if failed > 0 {
+			return fmt.Errorf("failed to verify %d cached theme version(s)", failed)
+		}
+
+		return nil
+	},
+}
@@ -3,11 +3,16 @@ package cmd
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
-	"strings"
+	"net/url"
 	"time"
 
+	"github.com/a3chron/stellar/internal/api"
+	"github.com/a3chron/stellar/internal/config"
+	"github.com/a3chron/stellar/internal/download"
+	"github.com/a3chron/stellar/internal/theme"
 	"github.com/spf13/cobra"
 )
 
@@ -36,8 +41,10 @@ func SetVersionInfo(version, commit, date string) {
 	versionInfo.date = date
 	// Also set the version for the root command to enable --version flag
 	rootCmd.Version = version
-	// Set custom version template to show ASCII art and check for updates
-	rootCmd.SetVersionTemplate(getFullVersionOutput())
+	// Set custom version template to show ASCII art and check for updates.
+	// The output is only built when --version is actually used, after flags like --offline are parsed.
+	cobra.AddTemplateFunc("fullVersionOutput", getFullVersionOutput)
+	rootCmd.SetVersionTemplate("{{fullVersionOutput}}")
 }
 
 // IsDev returns true if running a development build
@@ -50,6 +57,8 @@ type GitHubRelease struct {
 	Name        string    `json:"name"`
 	PublishedAt time.Time `json:"published_at"`
 	HTMLURL     string    `json:"html_url"`
+	Prerelease  bool      `json:"prerelease"`
+	Draft       bool      `json:"draft"`
 }
 
 var versionCmd = &cobra.Command{
@@ -69,8 +78,14 @@ func getFullVersionOutput() string {
 
 	// Check for updates if not dev version
 	if versionInfo.version != "dev" {
-		buf.WriteString("\nChecking for updates...\n")
-		buf.WriteString(checkForUpdates())
+		if isOffline() {
+			buf.WriteString("\nSkipping update check (offline mode)\n")
+		} else if !updateChecksEnabled() {
+			buf.WriteString("\nSkipping update check (check_updates is disabled)\n")
+		} else {
+			buf.WriteString("\nChecking for updates...\n")
+			buf.WriteString(checkForUpdates())
+		}
 	}
 
 	return buf.String()
@@ -124,65 +139,139 @@ func getVersionAsciiArt() string {
 	return buf.String()
 }
 
-// GetLatestRelease fetches the latest GitHub release information
-func GetLatestRelease() (*GitHubRelease, error) {
+// releasesAPIURL is the GitHub API endpoint for stellar's releases
+const releasesAPIURL = "https://api.github.com/repos/a3chron/stellar/releases"
+
+// maxReleasesPage is how many releases are looked at to find the newest prerelease
+const maxReleasesPage = 30
+
+// fetchReleaseInfo fetches endpoint from the GitHub API and decodes the JSON response into v
+func fetchReleaseInfo(endpoint string, v interface{}) error {
+	if isOffline() {
+		return api.ErrOffline
+	}
+
 	client := &http.Client{Timeout: 5 * time.Second}
 
-	resp, err := client.Get("https://api.github.com/repos/a3chron/stellar/releases/latest")
+	resp, err := client.Get(endpoint)
 	if err != nil {
-		return nil, fmt.Errorf("failed to check for updates: %w", err)
+		return fmt.Errorf("failed to check for updates: %w", err)
 	}
 	defer func() {
 		_ = resp.Body.Close()
 	}()
 
+	if resp.StatusCode == http.StatusNotFound {
+		return errReleaseNotFound
+	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to fetch latest release (status: %d)", resp.StatusCode)
+		return fmt.Errorf("failed to fetch release info (status: %d)", resp.StatusCode)
 	}
 
-	var release GitHubRelease
-	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
-		return nil, fmt.Errorf("failed to parse release info: %w", err)
+	body, err := download.Read(resp, download.Options{MaxSize: maxReleaseInfoSize})
+	if err != nil {
+		return fmt.Errorf("failed to read release info: %w", err)
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to parse release info: %w", err)
+	}
+
+	return nil
+}
+
+// errReleaseNotFound is returned when GitHub does not know a release
+var errReleaseNotFound = errors.New("release not found")
+
+// GetLatestRelease fetches the newest GitHub release of a channel. Stable is what GitHub
+// marks as the latest release, prerelease is the highest version of any recent release.
+func GetLatestRelease(channel string) (*GitHubRelease, error) {
+	if channel != config.UpdateChannelPrerelease {
+		var release GitHubRelease
+		if err := fetchReleaseInfo(releasesAPIURL+"/latest", &release); err != nil {
+			return nil, err
+		}
+		return &release, nil
+	}
+
+	var releases []GitHubRelease
+	if err := fetchReleaseInfo(fmt.Sprintf("%s?per_page=%d", releasesAPIURL, maxReleasesPage), &releases); err != nil {
+		return nil, err
 	}
 
+	var latest *GitHubRelease
+	for i, release := range releases {
+		if release.Draft {
+			continue
+		}
+		if latest == nil || theme.CompareVersions(release.TagName, latest.TagName) > 0 {
+			latest = &releases[i]
+		}
+	}
+	if latest == nil {
+		return nil, errReleaseNotFound
+	}
+
+	return latest, nil
+}
+
+// GetRelease fetches the GitHub release with the given tag, e.g. "v1.4.0"
+func GetRelease(tag string) (*GitHubRelease, error) {
+	var release GitHubRelease
+	if err := fetchReleaseInfo(fmt.Sprintf("%s/tags/%s", releasesAPIURL, url.PathEscape(tag)), &release); err != nil {
+		if errors.Is(err, errReleaseNotFound) {
+			return nil, fmt.Errorf("no release %s found", tag)
+		}
+		return nil, err
+	}
 	return &release, nil
 }
 
-// IsUpdateAvailable checks if a newer version is available
+// isNewerVersion reports whether the release tag is newer than the running version.
+// Local builds newer than the latest release are not "updated" to an older one.
+func isNewerVersion(tag string) bool {
+	return theme.CompareVersions(tag, versionInfo.version) > 0
+}
+
+// IsUpdateAvailable checks if a newer version is available on the configured channel
 func IsUpdateAvailable() (bool, string, error) {
 	if IsDev() {
 		return false, "dev", nil
 	}
 
-	release, err := GetLatestRelease()
+	channel, err := updateChannel()
 	if err != nil {
 		return false, "", err
 	}
 
-	latestVersion := strings.TrimPrefix(release.TagName, "v")
-	currentVersion := strings.TrimPrefix(versionInfo.version, "v")
+	release, err := GetLatestRelease(channel)
+	if err != nil {
+		return false, "", err
+	}
 
-	return latestVersion != currentVersion, release.TagName, nil
+	return isNewerVersion(release.TagName), release.TagName, nil
 }
 
 func checkForUpdates() string {
 	var buf bytes.Buffer
 
-	release, err := GetLatestRelease()
-	if err != nil {
-		fmt.Fprintf(&buf, "Failed to check for updates: %v\n", err)
-		return buf.String()
+	// Use the cached result if it is recent enough, otherwise ask GitHub (again, if the last check failed)
+	state := loadUpdateCheck()
+	if state == nil || !state.isFresh() || state.LastError != "" {
+		var err error
+		state, err = refreshUpdateCheck()
+		if err != nil {
+			fmt.Fprintf(&buf, "Failed to check for updates: %v\n", err)
+			return buf.String()
+		}
 	}
 
-	latestVersion := strings.TrimPrefix(release.TagName, "v")
-	currentVersion := strings.TrimPrefix(versionInfo.version, "v")
-
-	if latestVersion == currentVersion {
-		fmt.Fprintf(&buf, "%s  You have the latest version (%s)%s\n", colorGreen, release.TagName, colorReset)
+	if !state.hasUpdate() {
+		fmt.Fprintf(&buf, "%s  You have the latest version (%s)%s\n", colorGreen, state.LatestVersion, colorReset)
 	} else {
-		fmt.Fprintf(&buf, "%s  New version available: %s (current: %s)%s\n", colorYellow, release.TagName, versionInfo.version, colorReset)
-		fmt.Fprintf(&buf, "  Released: %s\n", release.PublishedAt.Format("2006-01-02"))
-		fmt.Fprintf(&buf, "  View release: %s\n", release.HTMLURL)
+		fmt.Fprintf(&buf, "%s  New version available: %s (current: %s)%s\n", colorYellow, state.LatestVersion, versionInfo.version, colorReset)
+		fmt.Fprintf(&buf, "  Released: %s\n", state.PublishedAt.Format("2006-01-02"))
+		fmt.Fprintf(&buf, "  View release: %s\n", state.HTMLURL)
 		buf.WriteString("\nTo update, run:\n")
 		buf.WriteString("  stellar update\n")
 	}
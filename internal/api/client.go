@@ -1,35 +1,79 @@
 package api
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/a3chron/stellar/internal/download"
+	"github.com/a3chron/stellar/internal/theme"
 )
 
+// BaseURL is the default stellar hub, used when no other hub is configured
 const BaseURL = "https://stellar-hub.vercel.app"
 
 type Client struct {
-	baseURL    string
-	httpClient *http.Client
+	baseURL     string
+	httpClient  *http.Client
+	metadataTTL time.Duration
+	offline     bool
+	progress    download.ProgressFunc
 }
 
-func NewClient() *Client {
+// NewClient creates a client for the hub at baseURL.
+// An empty baseURL falls back to the default hub.
+func NewClient(baseURL string) *Client {
+	baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	if baseURL == "" {
+		baseURL = BaseURL
+	}
+
 	return &Client{
-		baseURL: BaseURL,
+		baseURL: baseURL,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		metadataTTL: DefaultMetadataTTL,
 	}
 }
 
+// SetMetadataTTL sets how long cached theme metadata is used without revalidating.
+// A TTL of 0 revalidates with the hub on every request.
+func (c *Client) SetMetadataTTL(ttl time.Duration) {
+	c.metadataTTL = max(ttl, 0)
+}
+
+// SetOffline disables all network access. Requests are answered from the
+// metadata cache where possible and fail with ErrOffline otherwise.
+func (c *Client) SetOffline(offline bool) {
+	c.offline = offline
+}
+
+// SetProgress sets a function that is called while theme configs are downloaded
+func (c *Client) SetProgress(progress download.ProgressFunc) {
+	c.progress = progress
+}
+
+// BaseURL returns the hub URL this client talks to
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
 // Author info nested in theme response
 type AuthorInfo struct {
 	ID    string  `json:"id"`
 	Name  string  `json:"name"`
 	Image *string `json:"image"`
 	Bio   *string `json:"bio"`
+
+	PublicKey string `json:"publicKey,omitempty"` // Minisign key the author signs versions with, "" if they don't
 }
 
 // Theme info from API
@@ -45,6 +89,10 @@ type ThemeInfo struct {
 	Versions    []VersionInfo `json:"versions"`
 	CreatedAt   string        `json:"createdAt"`
 	UpdatedAt   string        `json:"updatedAt"`
+
+	// Stale is set when the hub could not be reached (or offline mode is enabled)
+	// and an expired cached copy was returned
+	Stale bool `json:"-"`
 }
 
 // Version info
@@ -53,65 +101,331 @@ type VersionInfo struct {
 	VersionNotes string   `json:"versionNotes"`
 	Dependencies []string `json:"dependencies"`
 	CreatedAt    string   `json:"createdAt"`
+	SHA256       string   `json:"sha256,omitempty"`    // Hash of the config, hubs before checksums don't send it
+	Signature    string   `json:"signature,omitempty"` // Detached minisign signature of the config, "" if unsigned
+}
+
+// get performs a GET request, retrying network errors, rate limits and server errors
+// with exponential backoff. The returned response has status 200, or 304 if
+// conditional headers were sent and the resource is unchanged.
+func (c *Client) get(url string, header http.Header) (*http.Response, error) {
+	if c.offline {
+		return nil, ErrOffline
+	}
+
+	var lastErr error
+	var lastResp *http.Response
+
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(retryDelay(attempt, lastResp))
+		}
+
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			lastErr = fmt.Errorf("%w: %v", ErrNetwork, err)
+			lastResp = nil
+			continue
+		}
+
+		if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusNotModified {
+			return resp, nil
+		}
+
+		lastErr = statusError(resp)
+		lastResp = resp
+		_ = resp.Body.Close()
+
+		if !isRetryable(lastErr) {
+			return nil, lastErr
+		}
+		// Respect a long Retry-After instead of hammering the hub in the meantime
+		if err := retryAfterTooLong(lastErr, resp); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, lastErr
 }
 
-func (c *Client) FetchThemeConfig(author, name, version string) (string, error) {
-	url := fmt.Sprintf("%s/api/%s/%s/%s", c.baseURL, author, name, version)
+// getCached returns the body for url using the on-disk metadata cache.
+// Fresh entries are returned without a request, older ones are revalidated with
+// If-None-Match / If-Modified-Since. If the hub cannot be reached, an expired
+// entry is returned with stale set to true.
+func (c *Client) getCached(url string, ttl time.Duration) (body []byte, stale bool, err error) {
+	entry := loadCacheEntry(url)
+	if entry != nil && entry.isFresh(ttl) {
+		return entry.Body, false, nil
+	}
 
-	resp, err := c.httpClient.Get(url)
+	if c.offline {
+		if entry != nil {
+			return entry.Body, true, nil
+		}
+		return nil, false, ErrOffline
+	}
+
+	header := http.Header{}
+	if entry != nil {
+		if entry.ETag != "" {
+			header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	resp, err := c.get(url, header)
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch theme: %w", err)
+		if errors.Is(err, ErrNotFound) {
+			removeCacheEntry(url)
+			return nil, false, err
+		}
+		if entry != nil && isRetryable(err) {
+			return entry.Body, true, nil
+		}
+		return nil, false, err
 	}
 	defer func() {
 		_ = resp.Body.Close()
 	}()
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("server returned %d", resp.StatusCode)
+	if resp.StatusCode == http.StatusNotModified && entry != nil {
+		entry.FetchedAt = time.Now()
+		saveCacheEntry(entry)
+		return entry.Body, false, nil
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	body, err = readBody(resp, download.Options{MaxSize: maxMetadataSize})
 	if err != nil {
-		return "", err
+		return nil, false, err
 	}
 
-	return string(body), nil
+	if !json.Valid(body) {
+		return nil, false, invalidResponse("not valid JSON")
+	}
+
+	saveCacheEntry(&cacheEntry{
+		URL:          url,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		FetchedAt:    time.Now(),
+		Body:         body,
+	})
+
+	return body, false, nil
 }
 
-func (c *Client) GetThemeInfo(author, name string) (*ThemeInfo, error) {
-	url := fmt.Sprintf("%s/api/%s/%s", c.baseURL, author, name)
+// FetchThemeConfig downloads version of a theme, as returned by GetVersionInfo or listed in GetThemeInfo,
+// and checks it against the SHA-256 the hub lists for it
+func (c *Client) FetchThemeConfig(author, name string, version *VersionInfo) (string, error) {
+	url := fmt.Sprintf("%s/api/%s/%s/%s", c.baseURL, author, name, version.Version)
 
-	resp, err := c.httpClient.Get(url)
+	resp, err := c.get(url, nil)
 	if err != nil {
-		return nil, err
+		return "", fmt.Errorf("failed to fetch theme: %w", err)
 	}
 	defer func() {
 		_ = resp.Body.Close()
 	}()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("theme not found")
+	body, err := readBody(resp, download.Options{
+		MaxSize:      theme.MaxConfigSize,
+		ContentTypes: configContentTypes,
+		Progress:     c.progress,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch theme: %w", err)
 	}
 
-	var info ThemeInfo
-	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+	// Versions listed without a checksum are from hubs before checksums
+	if expected := version.SHA256; expected != "" {
+		sum := sha256.Sum256(body)
+		if actual := hex.EncodeToString(sum[:]); !strings.EqualFold(actual, expected) {
+			return "", fmt.Errorf("%w: %s/%s@%s has sha256 %s, the hub lists %s", ErrChecksumMismatch, author, name, version.Version, actual, expected)
+		}
+	}
+
+	return string(body), nil
+}
+
+// GetVersionInfo returns the theme info and the listed version equal to version, to download it with FetchThemeConfig.
+// Cached metadata that does not list the version is revalidated first, the version may have been published since.
+// Failing to get the metadata is an error, so checksum verification never silently fails open.
+func (c *Client) GetVersionInfo(author, name, version string) (*ThemeInfo, *VersionInfo, error) {
+	info, err := c.getThemeInfo(author, name, c.metadataTTL)
+	if err == nil && info.FindVersion(version) == nil && c.metadataTTL > 0 {
+		info, err = c.getThemeInfo(author, name, 0)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot verify %s/%s@%s, failed to fetch its checksum: %w", author, name, version, err)
+	}
+
+	v := info.FindVersion(version)
+	if v == nil {
+		return nil, nil, fmt.Errorf("%w: version %s of %s/%s is not listed", ErrNotFound, version, author, name)
+	}
+	return info, v, nil
+}
+
+// FindVersion returns the listed version equal to version, e.g. "1.2" for "1.2.0", nil if it is not listed
+func (t *ThemeInfo) FindVersion(version string) *VersionInfo {
+	for i, v := range t.Versions {
+		if v.Version == version || theme.CompareVersions(v.Version, version) == 0 {
+			return &t.Versions[i]
+		}
+	}
+	return nil
+}
+
+// configContentTypes are the media types a theme config may be served as
+var configContentTypes = []string{
+	"text/plain",
+	"application/toml",
+	"text/toml",
+	"application/x-toml",
+	"text/x-toml",
+	"application/octet-stream",
+}
+
+// readBody reads a response from the hub, responses that are too large or not what was
+// expected (e.g. an HTML error page) fail with ErrInvalidResponse
+func readBody(resp *http.Response, opts download.Options) ([]byte, error) {
+	body, err := download.Read(resp, opts)
+	if errors.Is(err, download.ErrTooLarge) || errors.Is(err, download.ErrContentType) {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidResponse, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNetwork, err)
+	}
+	return body, nil
+}
+
+func (c *Client) GetThemeInfo(author, name string) (*ThemeInfo, error) {
+	return c.getThemeInfo(author, name, c.metadataTTL)
+}
+
+// getThemeInfo returns the theme info, from the metadata cache if it is younger than ttl
+func (c *Client) getThemeInfo(author, name string, ttl time.Duration) (*ThemeInfo, error) {
+	url := fmt.Sprintf("%s/api/%s/%s", c.baseURL, author, name)
+
+	body, stale, err := c.getCached(url, ttl)
+	if err != nil {
 		return nil, err
 	}
 
+	var info ThemeInfo
+	if err := json.Unmarshal(body, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse theme info: %w", err)
+	}
+
+	// Versions end up in file names, nothing from the hub is used before it is validated
+	if err := info.validate(); err != nil {
+		removeCacheEntry(url)
+		return nil, fmt.Errorf("%s/%s: %w", author, name, err)
+	}
+	info.Stale = stale
+
 	return &info, nil
 }
 
 func (c *Client) IncrementDownloadCount(author, name string) error {
 	url := fmt.Sprintf("%s/api/%s/%s", c.baseURL, author, name)
 
-	// Simple POST to increment download count
+	if c.offline {
+		return ErrOffline
+	}
+
+	// Simple POST to increment download count, not retried since it is not idempotent
 	resp, err := c.httpClient.Post(url, "application/json", nil)
 	if err != nil {
-		return err
+		return fmt.Errorf("%w: %v", ErrNetwork, err)
 	}
 	defer func() {
 		_ = resp.Body.Close()
 	}()
 
+	if resp.StatusCode >= 300 {
+		return statusError(resp)
+	}
+
 	return nil
 }
+
+// SearchOptions holds the query and filters for a hub search
+type SearchOptions struct {
+	Query       string
+	ColorScheme string
+	Group       string
+	Author      string
+	Sort        string // "downloads" or "updated"
+	Page        int
+	Limit       int
+}
+
+// SearchResult is a single page of themes returned by the hub
+type SearchResult struct {
+	Themes     []ThemeInfo `json:"themes"`
+	Total      int         `json:"total"`
+	Page       int         `json:"page"`
+	TotalPages int         `json:"totalPages"`
+}
+
+// SearchThemes queries the hub for themes matching the given options
+func (c *Client) SearchThemes(opts SearchOptions) (*SearchResult, error) {
+	params := url.Values{}
+	if opts.Query != "" {
+		params.Set("q", opts.Query)
+	}
+	if opts.ColorScheme != "" {
+		params.Set("colorScheme", opts.ColorScheme)
+	}
+	if opts.Group != "" {
+		params.Set("group", opts.Group)
+	}
+	if opts.Author != "" {
+		params.Set("author", opts.Author)
+	}
+	if opts.Sort != "" {
+		params.Set("sort", opts.Sort)
+	}
+	if opts.Page > 0 {
+		params.Set("page", strconv.Itoa(opts.Page))
+	}
+	if opts.Limit > 0 {
+		params.Set("limit", strconv.Itoa(opts.Limit))
+	}
+
+	searchURL := fmt.Sprintf("%s/api/themes?%s", c.baseURL, params.Encode())
+
+	resp, err := c.get(searchURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search themes: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	body, err := readBody(resp, download.Options{MaxSize: maxMetadataSize})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search themes: %w", err)
+	}
+
+	var result SearchResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse search results: %w", err)
+	}
+	if err := result.validate(); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
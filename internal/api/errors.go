@@ -0,0 +1,107 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Errors returned by Client methods, check them with errors.Is
+var (
+	ErrNotFound    = errors.New("not found on hub")
+	ErrRateLimited = errors.New("rate limited by hub")
+	ErrServer      = errors.New("hub server error")
+	ErrNetwork     = errors.New("could not reach hub")
+	ErrOffline     = errors.New("offline mode is enabled")
+
+	// ErrInvalidResponse is returned when a hub response does not match the expected schema
+	ErrInvalidResponse = errors.New("invalid response from hub")
+	// ErrChecksumMismatch is returned when a downloaded config does not match the SHA-256 listed by the hub
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+)
+
+const (
+	maxRetries     = 3
+	initialBackoff = 500 * time.Millisecond
+	maxBackoff     = 10 * time.Second
+)
+
+// StatusError describes a non-200 response from the hub.
+// It wraps one of the sentinel errors above.
+type StatusError struct {
+	StatusCode int
+	URL        string
+	kind       error
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s (status: %d)", e.kind, e.StatusCode)
+}
+
+func (e *StatusError) Unwrap() error {
+	return e.kind
+}
+
+// statusError maps an HTTP status code to a typed error
+func statusError(resp *http.Response) error {
+	var kind error
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		kind = ErrNotFound
+	case resp.StatusCode == http.StatusTooManyRequests:
+		kind = ErrRateLimited
+	case resp.StatusCode >= 500:
+		kind = ErrServer
+	default:
+		kind = fmt.Errorf("unexpected response from hub")
+	}
+
+	return &StatusError{StatusCode: resp.StatusCode, URL: resp.Request.URL.String(), kind: kind}
+}
+
+// isRetryable reports whether a failed request is worth trying again
+func isRetryable(err error) bool {
+	return errors.Is(err, ErrNetwork) || errors.Is(err, ErrRateLimited) || errors.Is(err, ErrServer)
+}
+
+// retryDelay returns how long to wait before the given attempt (starting at 1).
+// A Retry-After header from the previous response takes priority over exponential backoff,
+// get gives up instead of retrying if it asks for longer than maxBackoff.
+func retryDelay(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if d, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			return d
+		}
+	}
+
+	delay := initialBackoff << (attempt - 1)
+	return min(delay, maxBackoff)
+}
+
+// parseRetryAfter parses a Retry-After header, which is either seconds or an HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		return max(time.Until(t), 0), true
+	}
+
+	return 0, false
+}
+
+// retryAfterTooLong returns an error wrapping err if resp asks to wait longer than maxBackoff before retrying
+func retryAfterTooLong(err error, resp *http.Response) error {
+	d, ok := parseRetryAfter(resp.Header.Get("Retry-After"))
+	if !ok || d <= maxBackoff {
+		return nil
+	}
+	return fmt.Errorf("%w, try again in %s", err, d.Round(time.Second))
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultMetadataTTL is how long cached theme metadata is used without asking the hub
+const DefaultMetadataTTL = 5 * time.Minute
+
+// cacheEntry is a cached hub response together with its validators
+type cacheEntry struct {
+	URL          string          `json:"url"`
+	ETag         string          `json:"etag,omitempty"`
+	LastModified string          `json:"last_modified,omitempty"`
+	FetchedAt    time.Time       `json:"fetched_at"`
+	Body         json.RawMessage `json:"body"`
+}
+
+// MetadataCacheDir returns the directory for cached hub responses (~/.config/stellar/.cache/api)
+func MetadataCacheDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "stellar", ".cache", "api"), nil
+}
+
+// cacheEntryPath returns the file a response for url is cached in
+func cacheEntryPath(url string) (string, error) {
+	dir, err := MetadataCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(dir, hex.EncodeToString(sum[:])+".json"), nil
+}
+
+// loadCacheEntry returns the cached response for url, or nil if there is none
+func loadCacheEntry(url string) *cacheEntry {
+	path, err := cacheEntryPath(url)
+	if err != nil {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.URL != url {
+		return nil
+	}
+
+	return &entry
+}
+
+// saveCacheEntry writes entry to disk. Failures are ignored, the cache is best effort.
+func saveCacheEntry(entry *cacheEntry) {
+	path, err := cacheEntryPath(entry.URL)
+	if err != nil {
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	// Write to a temp file of our own first, so neither a concurrent reader nor another
+	// stellar process writing the same entry ever sees a partial entry
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+}
+
+// removeCacheEntry drops the cached response for url
+func removeCacheEntry(url string) {
+	if path, err := cacheEntryPath(url); err == nil {
+		_ = os.Remove(path)
+	}
+}
+
+// isFresh reports whether the entry can be used without revalidating
+func (e *cacheEntry) isFresh(ttl time.Duration) bool {
+	return ttl > 0 && time.Since(e.FetchedAt) < ttl
+}
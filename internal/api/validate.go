@@ -0,0 +1,185 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/a3chron/stellar/internal/signature"
+	"github.com/a3chron/stellar/internal/theme"
+)
+
+// Limits for hub responses. Themes are identified by author/slug@version and these
+// end up in file paths, so they are held to the same rules as theme identifiers.
+const (
+	maxMetadataSize    = 1 << 20 // Size of a JSON response
+	maxSlugLength      = 64
+	maxVersionLength   = 64
+	maxTextLength      = 256  // Single line fields like names
+	maxLongTextLength  = 8192 // Descriptions and version notes
+	maxVersions        = 1000
+	maxDependencies    = 100
+	maxSearchPageItems = 1000
+	maxSignatureLength = 1024 // Public keys and .minisig files, including their comments
+)
+
+// slugRegex matches author names and theme slugs, same as in theme identifiers
+var slugRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// sha256Regex matches a hex encoded SHA-256
+var sha256Regex = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// invalidResponse creates an ErrInvalidResponse describing what is wrong
+func invalidResponse(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidResponse, fmt.Sprintf(format, args...))
+}
+
+func validateSlug(field, s string) error {
+	if len(s) == 0 || len(s) > maxSlugLength || !slugRegex.MatchString(s) {
+		return invalidResponse("%s %q is not a valid slug", field, s)
+	}
+	return nil
+}
+
+func validateVersion(v string) error {
+	if len(v) > maxVersionLength {
+		return invalidResponse("version is longer than %d characters", maxVersionLength)
+	}
+	if _, ok := theme.ParseVersion(v); !ok {
+		return invalidResponse("version %q is not a valid version", v)
+	}
+	return nil
+}
+
+// textField is a free text field of a response
+type textField struct {
+	name      string
+	value     string
+	maxLength int
+	multiline bool // Allows newlines and tabs
+}
+
+// validateText checks the length of a free text field, and that it cannot mess with the terminal
+func validateText(field, s string, maxLength int, multiline bool) error {
+	if len(s) > maxLength {
+		return invalidResponse("%s is longer than %d characters", field, maxLength)
+	}
+	for i, r := range s {
+		// Text from web forms has \r\n line endings, a lone \r could overwrite what was printed before it
+		if multiline && (r == '\n' || r == '\t' || (r == '\r' && strings.HasPrefix(s[i+1:], "\n"))) {
+			continue
+		}
+		if unicode.IsControl(r) || r == '\u2028' || r == '\u2029' {
+			return invalidResponse("%s contains control characters", field)
+		}
+	}
+	return nil
+}
+
+// validate checks a theme from the hub against the schema
+func (t *ThemeInfo) validate() error {
+	if err := validateSlug("author", t.Author.Name); err != nil {
+		return err
+	}
+	if err := validateSlug("slug", t.Slug); err != nil {
+		return err
+	}
+
+	fields := []textField{
+		{"id", t.ID, maxTextLength, false},
+		{"author id", t.Author.ID, maxTextLength, false},
+		{"name", t.Name, maxTextLength, false},
+		{"description", t.Description, maxLongTextLength, true},
+		{"group", t.Group, maxTextLength, false},
+		{"createdAt", t.CreatedAt, maxTextLength, false},
+		{"updatedAt", t.UpdatedAt, maxTextLength, false},
+	}
+	if t.ColorScheme != nil {
+		fields = append(fields, textField{"colorScheme", *t.ColorScheme, maxTextLength, false})
+	}
+	if t.Author.Bio != nil {
+		fields = append(fields, textField{"author bio", *t.Author.Bio, maxLongTextLength, true})
+	}
+	if t.Author.PublicKey != "" {
+		if len(t.Author.PublicKey) > maxSignatureLength {
+			return invalidResponse("author public key is longer than %d characters", maxSignatureLength)
+		}
+		if _, err := signature.ParsePublicKey(t.Author.PublicKey); err != nil {
+			return invalidResponse("author public key: %v", err)
+		}
+	}
+	for _, f := range fields {
+		if err := validateText(f.name, f.value, f.maxLength, f.multiline); err != nil {
+			return err
+		}
+	}
+
+	if t.Downloads < 0 {
+		return invalidResponse("negative download count")
+	}
+
+	if len(t.Versions) > maxVersions {
+		return invalidResponse("more than %d versions", maxVersions)
+	}
+	for _, v := range t.Versions {
+		if err := v.validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validate checks a version from the hub against the schema
+func (v *VersionInfo) validate() error {
+	if err := validateVersion(v.Version); err != nil {
+		return err
+	}
+	if v.SHA256 != "" && !sha256Regex.MatchString(v.SHA256) {
+		return invalidResponse("sha256 of version %s is not a valid SHA-256", v.Version)
+	}
+	if v.Signature != "" {
+		if len(v.Signature) > maxSignatureLength {
+			return invalidResponse("signature of version %s is longer than %d characters", v.Version, maxSignatureLength)
+		}
+		if _, err := signature.ParseSignature(v.Signature); err != nil {
+			return invalidResponse("signature of version %s: %v", v.Version, err)
+		}
+	}
+	if err := validateText("version notes", v.VersionNotes, maxLongTextLength, true); err != nil {
+		return err
+	}
+	if err := validateText("version createdAt", v.CreatedAt, maxTextLength, false); err != nil {
+		return err
+	}
+
+	if len(v.Dependencies) > maxDependencies {
+		return invalidResponse("more than %d dependencies", maxDependencies)
+	}
+	for _, dep := range v.Dependencies {
+		if err := validateText("dependency", dep, maxTextLength, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validate checks a page of search results against the schema
+func (r *SearchResult) validate() error {
+	if len(r.Themes) > maxSearchPageItems {
+		return invalidResponse("more than %d themes on a page", maxSearchPageItems)
+	}
+	if r.Total < 0 || r.Page < 0 || r.TotalPages < 0 {
+		return invalidResponse("negative page numbers")
+	}
+
+	for i := range r.Themes {
+		if err := r.Themes[i].validate(); err != nil {
+			return fmt.Errorf("theme %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
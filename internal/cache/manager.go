@@ -1,6 +1,8 @@
 package cache
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"log"
 	"os"
@@ -31,7 +33,67 @@ func SaveTheme(t *theme.Theme, content string) error {
 		return err
 	}
 
-	return os.WriteFile(path, []byte(content), 0644)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return err
+	}
+
+	// Record the hash of what was downloaded, so VerifyTheme can tell when the file changes
+	sum := sha256.Sum256([]byte(content))
+	record := fmt.Sprintf("%s  %s\n", hex.EncodeToString(sum[:]), filepath.Base(path))
+	return os.WriteFile(checksumPath(path), []byte(record), 0644)
+}
+
+// checksumPath returns the file the hash of the cached config at path is recorded in,
+// e.g. 1.2.toml.sha256 in the format of sha256sum
+func checksumPath(path string) string {
+	return path + ".sha256"
+}
+
+// VerifyTheme compares the cached config of t with the hash recorded when it was saved.
+// Returns both hashes, expected is "" if no hash was recorded (e.g. themes cached by older versions).
+func VerifyTheme(t *theme.Theme) (expected, actual string, err error) {
+	path, err := t.CachePath()
+	if err != nil {
+		return "", "", err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", err
+	}
+	sum := sha256.Sum256(data)
+	actual = hex.EncodeToString(sum[:])
+
+	record, err := os.ReadFile(checksumPath(path))
+	if os.IsNotExist(err) {
+		return "", actual, nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	fields := strings.Fields(string(record))
+	if len(fields) == 0 {
+		return "", "", fmt.Errorf("empty checksum file %s", checksumPath(path))
+	}
+
+	return strings.ToLower(fields[0]), actual, nil
+}
+
+// RemoveTheme deletes the cached config of t together with its recorded hash and variant without custom modules
+func RemoveTheme(t *theme.Theme) error {
+	path, err := t.CachePath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	if err := os.Remove(checksumPath(path)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return RemoveStrippedVariant(t)
 }
 
 func ThemeExists(t *theme.Theme) bool {
@@ -44,6 +106,76 @@ func ThemeExists(t *theme.Theme) bool {
 	return err == nil
 }
 
+// strippedDir is the hidden directory inside a theme directory holding the variants without custom modules
+const strippedDir = ".stripped"
+
+// StrippedPath returns where the variant of t without custom modules is stored,
+// e.g. ~/.config/stellar/author/theme/.stripped/1.2.toml
+func StrippedPath(t *theme.Theme) (string, error) {
+	path, err := t.CachePath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), strippedDir, filepath.Base(path)), nil
+}
+
+// IsStrippedPath reports whether path is a variant created by SaveStrippedVariant
+func IsStrippedPath(path string) bool {
+	return filepath.Base(filepath.Dir(path)) == strippedDir
+}
+
+// SaveStrippedVariant derives the variant of the cached theme t without custom modules and returns its path.
+// The variant is regenerated from the cached theme every time, so it always matches the upstream version.
+func SaveStrippedVariant(t *theme.Theme) (string, error) {
+	path, err := t.CachePath()
+	if err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	stripped, err := theme.StripCustom(string(data))
+	if err != nil {
+		return "", err
+	}
+
+	variantPath, err := StrippedPath(t)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(variantPath), 0755); err != nil {
+		return "", err
+	}
+
+	if err := os.WriteFile(variantPath, []byte(stripped), 0644); err != nil {
+		return "", err
+	}
+
+	return variantPath, nil
+}
+
+// RemoveStrippedVariant deletes the variant of t without custom modules, if there is one
+func RemoveStrippedVariant(t *theme.Theme) error {
+	variantPath, err := StrippedPath(t)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(variantPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	// Remove the variant directory once it is empty
+	if isEmpty, _ := isDirEmpty(filepath.Dir(variantPath)); isEmpty {
+		return os.Remove(filepath.Dir(variantPath))
+	}
+	return nil
+}
+
 func ListCachedThemes() ([]string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
@@ -61,7 +193,8 @@ func ListCachedThemes() ([]string, error) {
 	}
 
 	for _, author := range authors {
-		if !author.IsDir() || author.Name() == "config.json" {
+		// Skip files and hidden directories like .cache
+		if !author.IsDir() || strings.HasPrefix(author.Name(), ".") {
 			continue
 		}
 
@@ -115,13 +248,14 @@ func CleanCache(excludeCurrentPath string) error {
 			continue
 		}
 
-		// Compare by actual file path
-		if excludeCurrentPath != "" && path == excludeCurrentPath {
+		// Compare by actual file path, the current theme might be the variant without custom modules
+		variantPath, _ := StrippedPath(t)
+		if excludeCurrentPath != "" && (path == excludeCurrentPath || variantPath == excludeCurrentPath) {
 			continue
 		}
 
-		// Remove the theme file
-		if err := os.Remove(path); err != nil {
+		// Remove the theme file, its hash and variant
+		if err := RemoveTheme(t); err != nil {
 			log.Printf("warning: failed to remove %s: %v", path, err)
 		}
 
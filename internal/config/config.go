@@ -1,19 +1,44 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 )
 
 type Config struct {
-	CurrentTheme     string   `json:"current_theme"`      // "alice/rainbow@1.2"
-	CurrentPath      string   `json:"current_path"`       // Full path to .toml
-	PreviousTheme    string   `json:"previous_theme,omitempty"`
-	PreviousPath     string   `json:"previous_path,omitempty"`
-	DownloadedThemes []string `json:"downloaded_themes,omitempty"` // ["alice/rainbow", "bob/sunset"]
+	CurrentTheme     string            `json:"current_theme"` // "alice/rainbow@1.2"
+	CurrentPath      string            `json:"current_path"`  // Full path to .toml
+	PreviousTheme    string            `json:"previous_theme,omitempty"`
+	PreviousPath     string            `json:"previous_path,omitempty"`
+	DownloadedThemes []string          `json:"downloaded_themes,omitempty"` // ["alice/rainbow", "bob/sunset"]
+	HubURL           string            `json:"hub_url,omitempty"`           // Overrides the default stellar hub
+	MetadataTTL      string            `json:"metadata_ttl,omitempty"`      // How long hub metadata is cached, e.g. "10m"
+	CheckUpdates     *bool             `json:"check_updates,omitempty"`     // Defaults to true when unset
+	Pins             map[string]string `json:"pins,omitempty"`              // {"alice/rainbow": "1.2"}
+	Constraints      map[string]string `json:"constraints,omitempty"`       // {"alice/rainbow": "^1.2"}
+	StripCustom      []string          `json:"strip_custom,omitempty"`      // Themes applied without their [custom] modules
+	CustomCommands   string            `json:"custom_commands,omitempty"`   // prompt, allow, deny or strip, see CustomCommandsPolicy
+	UpdateChannel    string            `json:"update_channel,omitempty"`    // stable or prerelease, see ReleaseChannel
+	PreviousVersion  string            `json:"previous_version,omitempty"`  // Version of the binary kept by update, for update --rollback
 }
 
+// Ways to handle themes that run commands which were not approved before
+const (
+	CustomCommandsPrompt = "prompt" // Ask, refuse when there is no terminal to ask on
+	CustomCommandsAllow  = "allow"  // Use the theme without asking
+	CustomCommandsDeny   = "deny"   // Refuse the theme
+	CustomCommandsStrip  = "strip"  // Use the theme without its [custom] modules
+)
+
+// Release channels stellar update installs from
+const (
+	UpdateChannelStable     = "stable"     // Only full releases
+	UpdateChannelPrerelease = "prerelease" // Prereleases too, whichever is newest
+)
+
 func ConfigPath() (string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
@@ -55,12 +80,16 @@ func (c *Config) Save() error {
 		return err
 	}
 
-	data, err := json.MarshalIndent(c, "", "  ")
-	if err != nil {
+	// Don't escape "<" and ">", version constraints like ">=1.0 <2.0" should stay readable
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(c); err != nil {
 		return err
 	}
 
-	return os.WriteFile(path, data, 0644)
+	return os.WriteFile(path, buf.Bytes(), 0644)
 }
 
 // HasDownloaded checks if a theme (author/slug) was previously downloaded
@@ -79,3 +108,104 @@ func (c *Config) MarkDownloaded(themeID string) {
 		c.DownloadedThemes = append(c.DownloadedThemes, themeID)
 	}
 }
+
+// UpdateChecksEnabled reports whether stellar should check for new releases
+func (c *Config) UpdateChecksEnabled() bool {
+	return c.CheckUpdates == nil || *c.CheckUpdates
+}
+
+// PinnedVersion returns the version a theme (author/slug) is pinned to, if any
+func (c *Config) PinnedVersion(themeID string) (string, bool) {
+	version, ok := c.Pins[themeID]
+	return version, ok
+}
+
+// Pin pins a theme (author/slug) to a version
+func (c *Config) Pin(themeID, version string) {
+	if c.Pins == nil {
+		c.Pins = make(map[string]string)
+	}
+	c.Pins[themeID] = version
+}
+
+// Unpin removes the pin of a theme, returns false if it was not pinned
+func (c *Config) Unpin(themeID string) bool {
+	if _, ok := c.Pins[themeID]; !ok {
+		return false
+	}
+	delete(c.Pins, themeID)
+	return true
+}
+
+// VersionConstraint returns the version range a theme (author/slug) was applied with, if any
+func (c *Config) VersionConstraint(themeID string) (string, bool) {
+	constraint, ok := c.Constraints[themeID]
+	return constraint, ok
+}
+
+// SetVersionConstraint records the version range a theme was applied with
+func (c *Config) SetVersionConstraint(themeID, constraint string) {
+	if c.Constraints == nil {
+		c.Constraints = make(map[string]string)
+	}
+	c.Constraints[themeID] = constraint
+}
+
+// ClearVersionConstraint forgets the version range of a theme
+func (c *Config) ClearVersionConstraint(themeID string) {
+	delete(c.Constraints, themeID)
+}
+
+// StripsCustom reports whether a theme (author/slug) is applied without its custom modules
+func (c *Config) StripsCustom(themeID string) bool {
+	for _, t := range c.StripCustom {
+		if t == themeID {
+			return true
+		}
+	}
+	return false
+}
+
+// SetStripCustom records whether a theme should be applied without its custom modules
+func (c *Config) SetStripCustom(themeID string, strip bool) {
+	if strip == c.StripsCustom(themeID) {
+		return
+	}
+
+	if strip {
+		c.StripCustom = append(c.StripCustom, themeID)
+		return
+	}
+
+	kept := c.StripCustom[:0]
+	for _, t := range c.StripCustom {
+		if t != themeID {
+			kept = append(kept, t)
+		}
+	}
+	c.StripCustom = kept
+}
+
+// CustomCommandsPolicy returns how themes with unapproved commands are handled, prompt by default
+func (c *Config) CustomCommandsPolicy() (string, error) {
+	switch c.CustomCommands {
+	case "":
+		return CustomCommandsPrompt, nil
+	case CustomCommandsPrompt, CustomCommandsAllow, CustomCommandsDeny, CustomCommandsStrip:
+		return c.CustomCommands, nil
+	default:
+		return "", fmt.Errorf("invalid custom_commands %q in config.json (use prompt, allow, deny or strip)", c.CustomCommands)
+	}
+}
+
+// ReleaseChannel returns the channel stellar update installs from, stable by default
+func (c *Config) ReleaseChannel() (string, error) {
+	switch c.UpdateChannel {
+	case "":
+		return UpdateChannelStable, nil
+	case UpdateChannelStable, UpdateChannelPrerelease:
+		return c.UpdateChannel, nil
+	default:
+		return "", fmt.Errorf("invalid update_channel %q in config.json (use stable or prerelease)", c.UpdateChannel)
+	}
+}
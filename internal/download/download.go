@@ -0,0 +1,121 @@
+package download
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Errors returned when a response is not what was expected, check them with errors.Is
+var (
+	ErrTooLarge    = errors.New("response is too large")
+	ErrContentType = errors.New("unexpected content type")
+)
+
+// ProgressFunc is called while a response body is read, with the bytes read so far
+// and the expected total, which is -1 if the server did not send a Content-Length
+type ProgressFunc func(read, total int64)
+
+// Options describe what a response has to look like
+type Options struct {
+	MaxSize      int64        // Reading is aborted as soon as the body gets larger, 0 for no limit
+	ContentTypes []string     // Allowed media types, a missing Content-Type is allowed too. Empty allows anything but HTML.
+	Progress     ProgressFunc // Optional
+}
+
+// Read reads the body of resp, see Copy
+func Read(resp *http.Response, opts Options) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := Copy(&buf, resp, opts); err != nil {
+		return nil, err
+	}
+
+	// Some servers answer errors with an HTML page and a 200 status, without saying it is HTML
+	if looksLikeHTML(buf.Bytes()) {
+		return nil, fmt.Errorf("%w: got an HTML page, probably an error page", ErrContentType)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Copy streams the body of resp to w. The Content-Type and Content-Length headers are checked
+// before anything is read, and the body is read through a limit, so oversized responses fail early.
+func Copy(w io.Writer, resp *http.Response, opts Options) (int64, error) {
+	if err := checkContentType(resp.Header.Get("Content-Type"), opts.ContentTypes); err != nil {
+		return 0, err
+	}
+
+	total := resp.ContentLength
+	if opts.MaxSize > 0 && total > opts.MaxSize {
+		return 0, fmt.Errorf("%w: %d bytes, the limit is %d", ErrTooLarge, total, opts.MaxSize)
+	}
+
+	var body io.Reader = resp.Body
+	if opts.MaxSize > 0 {
+		// Read one byte more than allowed, to tell a body of exactly MaxSize from a larger one
+		body = io.LimitReader(body, opts.MaxSize+1)
+	}
+	if opts.Progress != nil {
+		body = &progressReader{r: body, total: total, progress: opts.Progress}
+		opts.Progress(0, total)
+	}
+
+	n, err := io.Copy(w, body)
+	if err != nil {
+		return n, err
+	}
+	if opts.MaxSize > 0 && n > opts.MaxSize {
+		return n, fmt.Errorf("%w: more than %d bytes", ErrTooLarge, opts.MaxSize)
+	}
+
+	return n, nil
+}
+
+// checkContentType returns an error if contentType is not one of allowed, or HTML if allowed is empty
+func checkContentType(contentType string, allowed []string) error {
+	if contentType == "" {
+		return nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("%w: %q", ErrContentType, contentType)
+	}
+
+	if mediaType == "text/html" || mediaType == "application/xhtml+xml" {
+		return fmt.Errorf("%w: got an HTML page, probably an error page", ErrContentType)
+	}
+	if len(allowed) > 0 && !slices.Contains(allowed, mediaType) {
+		return fmt.Errorf("%w: got %s", ErrContentType, mediaType)
+	}
+
+	return nil
+}
+
+// looksLikeHTML reports whether data starts like an HTML document
+func looksLikeHTML(data []byte) bool {
+	start := strings.ToLower(strings.TrimSpace(string(data[:min(len(data), 512)])))
+	return strings.HasPrefix(start, "<!doctype html") || strings.HasPrefix(start, "<html")
+}
+
+// progressReader calls progress after every read
+type progressReader struct {
+	r        io.Reader
+	read     int64
+	total    int64
+	progress ProgressFunc
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.read += int64(n)
+		p.progress(p.read, p.total)
+	}
+	return n, err
+}
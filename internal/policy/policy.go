@@ -0,0 +1,222 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/a3chron/stellar/internal/theme"
+)
+
+// File is one policy file, e.g.
+//
+//	hub_url = "https://stellar.example.com"
+//	allow_authors = ["a3chron", "acme"]
+//	deny_themes = ["acme/legacy-*"]
+//	allow_custom_commands = false
+//	max_config_size = 51200
+type File struct {
+	Path                string   `toml:"-"`
+	HubURL              string   `toml:"hub_url"`               // The only hub stellar may talk to
+	AllowAuthors        []string `toml:"allow_authors"`         // If set with allow_themes, only these are allowed
+	DenyAuthors         []string `toml:"deny_authors"`          // Always blocked
+	AllowThemes         []string `toml:"allow_themes"`          // "author/theme", patterns like "acme/*" work
+	DenyThemes          []string `toml:"deny_themes"`           // Always blocked, takes priority over allow lists
+	AllowCustomCommands *bool    `toml:"allow_custom_commands"` // Defaults to true when unset
+	MaxConfigSize       int64    `toml:"max_config_size"`       // In bytes, 0 for no limit
+}
+
+// Policy are the policy files in effect. A theme has to pass the rules of every file,
+// so the user file can add restrictions to the system file but never lift them.
+type Policy struct {
+	Files []File
+}
+
+// Violation is returned when a policy rule blocks an action
+type Violation struct {
+	Rule    string // The setting that blocked the action, e.g. "deny_authors"
+	Path    string // The policy file it is set in
+	Message string
+}
+
+func (v *Violation) Error() string {
+	return fmt.Sprintf("%s (blocked by %s in %s)", v.Message, v.Rule, v.Path)
+}
+
+// SystemPath returns the location of the system-wide policy file
+func SystemPath() string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("ProgramData"), "stellar", "policy.toml")
+	}
+	return "/etc/stellar/policy.toml"
+}
+
+// UserPath returns the location of the user's policy file
+func UserPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "stellar", "policy.toml"), nil
+}
+
+// Load reads the system and user policy files, missing files are skipped.
+// An invalid file is an error, so a typo never silently lifts a restriction.
+func Load() (*Policy, error) {
+	paths := []string{SystemPath()}
+	if userPath, err := UserPath(); err == nil {
+		paths = append(paths, userPath)
+	}
+
+	p := &Policy{}
+	for _, path := range paths {
+		f, err := loadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		p.Files = append(p.Files, *f)
+	}
+
+	// Both files pinning different hubs would leave no hub stellar may talk to
+	var pinned *File
+	for i, f := range p.Files {
+		if f.HubURL == "" {
+			continue
+		}
+		if pinned != nil && normalizeURL(pinned.HubURL) != normalizeURL(f.HubURL) {
+			return nil, fmt.Errorf("conflicting hub_url in policy files: %s pins %s, %s pins %s", pinned.Path, pinned.HubURL, f.Path, f.HubURL)
+		}
+		pinned = &p.Files[i]
+	}
+
+	return p, nil
+}
+
+func loadFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f := File{Path: path}
+	meta, err := toml.Decode(string(data), &f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid policy file %s: %w", path, err)
+	}
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		return nil, fmt.Errorf("invalid policy file %s: unknown setting %q", path, undecoded[0].String())
+	}
+	if f.MaxConfigSize < 0 {
+		return nil, fmt.Errorf("invalid policy file %s: max_config_size cannot be negative", path)
+	}
+
+	return &f, nil
+}
+
+// HubURL returns the hub the policy pins stellar to, "" if any hub may be used
+func (p *Policy) HubURL() string {
+	for _, f := range p.Files {
+		if f.HubURL != "" {
+			return f.HubURL
+		}
+	}
+	return ""
+}
+
+// CheckHub returns a Violation if stellar may not talk to the hub at url
+func (p *Policy) CheckHub(url string) error {
+	for _, f := range p.Files {
+		if f.HubURL != "" && normalizeURL(f.HubURL) != normalizeURL(url) {
+			return &Violation{
+				Rule:    "hub_url",
+				Path:    f.Path,
+				Message: fmt.Sprintf("hub %s is not allowed, only %s may be used", url, f.HubURL),
+			}
+		}
+	}
+	return nil
+}
+
+// CheckTheme returns a Violation if the theme author/name may not be used
+func (p *Policy) CheckTheme(author, name string) error {
+	return p.checkTheme(author, name, true)
+}
+
+// CheckOwnTheme is CheckTheme for a config of the user's own, like the backup of their starship.toml.
+// Only the deny lists apply to it, the allow lists are about themes from the hub.
+func (p *Policy) CheckOwnTheme(author, name string) error {
+	return p.checkTheme(author, name, false)
+}
+
+func (p *Policy) checkTheme(author, name string, allowLists bool) error {
+	themeID := author + "/" + name
+
+	for _, f := range p.Files {
+		if matchAny(f.DenyAuthors, author) {
+			return &Violation{Rule: "deny_authors", Path: f.Path, Message: fmt.Sprintf("themes by %s are not allowed", author)}
+		}
+		if matchAny(f.DenyThemes, themeID) {
+			return &Violation{Rule: "deny_themes", Path: f.Path, Message: fmt.Sprintf("%s is not allowed", themeID)}
+		}
+
+		if !allowLists || len(f.AllowAuthors) == 0 && len(f.AllowThemes) == 0 {
+			continue
+		}
+		if !matchAny(f.AllowAuthors, author) && !matchAny(f.AllowThemes, themeID) {
+			rule := "allow_authors"
+			if len(f.AllowAuthors) == 0 {
+				rule = "allow_themes"
+			} else if len(f.AllowThemes) > 0 {
+				rule = "allow_authors / allow_themes"
+			}
+			return &Violation{Rule: rule, Path: f.Path, Message: fmt.Sprintf("%s is not on the list of allowed themes", themeID)}
+		}
+	}
+
+	return nil
+}
+
+// CheckConfig returns a Violation if a theme config with the given content and commands may not be used
+func (p *Policy) CheckConfig(content string, commands []theme.Command) error {
+	for _, f := range p.Files {
+		if f.MaxConfigSize > 0 && int64(len(content)) > f.MaxConfigSize {
+			return &Violation{
+				Rule:    "max_config_size",
+				Path:    f.Path,
+				Message: fmt.Sprintf("config is %d bytes, more than the allowed %d", len(content), f.MaxConfigSize),
+			}
+		}
+
+		if f.AllowCustomCommands != nil && !*f.AllowCustomCommands && len(commands) > 0 {
+			return &Violation{
+				Rule:    "allow_custom_commands",
+				Path:    f.Path,
+				Message: fmt.Sprintf("config runs %d command(s), e.g. [%s] %s, which is not allowed", len(commands), commands[0].Module, commands[0].Option),
+			}
+		}
+	}
+
+	return nil
+}
+
+// matchAny reports whether s matches one of the patterns, see path.Match
+func matchAny(patterns []string, s string) bool {
+	for _, pattern := range patterns {
+		if ok, err := path.Match(pattern, s); ok && err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeURL(url string) string {
+	return strings.TrimRight(url, "/")
+}
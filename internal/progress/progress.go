@@ -0,0 +1,125 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/mattn/go-isatty"
+)
+
+const (
+	// delay before anything is shown, downloads that finish quicker stay silent
+	delay = 500 * time.Millisecond
+	// terminalInterval is how often the progress line is redrawn on a terminal
+	terminalInterval = 100 * time.Millisecond
+	// logInterval is how often a progress line is logged when not on a terminal
+	logInterval = 5 * time.Second
+)
+
+// Reporter shows the progress of a download. On a terminal it keeps a single line with
+// bytes, rate and ETA up to date, otherwise it logs a line every few seconds.
+// A nil Reporter reports nothing, so callers can pass one around unconditionally.
+type Reporter struct {
+	label    string
+	out      io.Writer
+	terminal bool
+
+	start   time.Time
+	last    time.Time // When progress was shown last, zero if never
+	read    int64
+	total   int64
+	started bool
+}
+
+// New creates a Reporter for downloading label, writing to stderr so it never mixes with output meant for pipes
+func New(label string) *Reporter {
+	fd := os.Stderr.Fd()
+	return &Reporter{
+		label:    label,
+		out:      os.Stderr,
+		terminal: isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd),
+	}
+}
+
+// Update records that read of total bytes were downloaded, total is -1 if unknown.
+// It can be used as a download.ProgressFunc.
+func (r *Reporter) Update(read, total int64) {
+	if r == nil {
+		return
+	}
+
+	now := time.Now()
+	if !r.started {
+		r.start = now
+		r.started = true
+	}
+	r.read, r.total = read, total
+
+	if now.Sub(r.start) < delay {
+		return
+	}
+
+	interval := logInterval
+	if r.terminal {
+		interval = terminalInterval
+	}
+	if !r.last.IsZero() && now.Sub(r.last) < interval {
+		return
+	}
+	r.last = now
+
+	if r.terminal {
+		// \r and "erase line", so a shorter line leaves nothing of the previous one behind
+		_, _ = fmt.Fprintf(r.out, "\r\033[K%s", r.status(now))
+	} else {
+		_, _ = fmt.Fprintln(r.out, r.status(now))
+	}
+}
+
+// Finish ends the report, leaving a summary if any progress was shown
+func (r *Reporter) Finish() {
+	if r == nil || r.last.IsZero() {
+		return
+	}
+
+	elapsed := time.Since(r.start).Round(100 * time.Millisecond)
+	summary := fmt.Sprintf("%s: %s in %s", r.label, FormatBytes(r.read), elapsed)
+	if r.terminal {
+		_, _ = fmt.Fprintf(r.out, "\r\033[K%s\n", summary)
+	} else {
+		_, _ = fmt.Fprintln(r.out, summary)
+	}
+}
+
+// status describes the progress so far, e.g. "stellar-linux-amd64: 1.2 MB / 8.0 MB (15%), 512.0 KB/s, 14s left"
+func (r *Reporter) status(now time.Time) string {
+	elapsed := now.Sub(r.start).Seconds()
+	rate := float64(r.read) / max(elapsed, 0.001)
+
+	if r.total <= 0 {
+		return fmt.Sprintf("%s: %s, %s/s", r.label, FormatBytes(r.read), FormatBytes(int64(rate)))
+	}
+
+	status := fmt.Sprintf("%s: %s / %s (%d%%), %s/s", r.label, FormatBytes(r.read), FormatBytes(r.total), r.read*100/r.total, FormatBytes(int64(rate)))
+	if rate > 0 && r.read < r.total {
+		eta := time.Duration(float64(r.total-r.read) / rate * float64(time.Second))
+		status += fmt.Sprintf(", %s left", eta.Round(time.Second))
+	}
+	return status
+}
+
+// FormatBytes formats a size like "1.2 MB"
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGT"[exp])
+}
@@ -0,0 +1,158 @@
+package signature
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// Errors returned by Verify, check them with errors.Is
+var (
+	ErrInvalid     = errors.New("invalid signature")
+	ErrWrongKey    = errors.New("signed with a different key")
+	ErrMalformed   = errors.New("malformed signature or key")
+	errUnsupported = errors.New("unsupported algorithm")
+)
+
+// Algorithms of minisign. "Ed" signs the content itself, "ED" (the default since minisign 0.11)
+// signs its BLAKE2b-512 hash. Keys are always "Ed".
+const (
+	algEd25519       = "Ed"
+	algHashedEd25519 = "ED"
+)
+
+const (
+	untrustedCommentPrefix = "untrusted comment: "
+	trustedCommentPrefix   = "trusted comment: "
+)
+
+// PublicKey is a minisign public key
+type PublicKey struct {
+	ID  [8]byte
+	Key ed25519.PublicKey
+}
+
+// ParsePublicKey parses a minisign public key, either the base64 line alone
+// or the whole .pub file including its untrusted comment
+func ParsePublicKey(s string) (*PublicKey, error) {
+	lines := nonEmptyLines(s)
+	if len(lines) > 0 && strings.HasPrefix(lines[0], untrustedCommentPrefix) {
+		lines = lines[1:]
+	}
+	if len(lines) != 1 {
+		return nil, fmt.Errorf("%w: expected a single base64 encoded public key", ErrMalformed)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(lines[0])
+	if err != nil || len(data) != 2+8+ed25519.PublicKeySize {
+		return nil, fmt.Errorf("%w: public key is not a base64 encoded minisign key", ErrMalformed)
+	}
+	if string(data[:2]) != algEd25519 {
+		return nil, fmt.Errorf("%w: public key algorithm %q", errUnsupported, data[:2])
+	}
+
+	key := &PublicKey{Key: ed25519.PublicKey(bytes.Clone(data[10:]))}
+	copy(key.ID[:], data[2:10])
+	return key, nil
+}
+
+// String returns the base64 line of the key, as in a .pub file
+func (k *PublicKey) String() string {
+	data := append([]byte(algEd25519), k.ID[:]...)
+	return base64.StdEncoding.EncodeToString(append(data, k.Key...))
+}
+
+// KeyID returns the key ID the way minisign prints it, e.g. "E6E4A1C1A4B3A2C1"
+func (k *PublicKey) KeyID() string {
+	return formatKeyID(k.ID)
+}
+
+// Equal reports whether k and other are the same key
+func (k *PublicKey) Equal(other *PublicKey) bool {
+	return k.ID == other.ID && k.Key.Equal(other.Key)
+}
+
+// Signature is a detached minisign signature, the content of a .minisig file
+type Signature struct {
+	Algorithm       string
+	KeyID           [8]byte
+	Signature       []byte
+	TrustedComment  string
+	GlobalSignature []byte
+}
+
+// ParseSignature parses the content of a .minisig file
+func ParseSignature(s string) (*Signature, error) {
+	lines := nonEmptyLines(s)
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], untrustedCommentPrefix) || !strings.HasPrefix(lines[2], trustedCommentPrefix) {
+		return nil, fmt.Errorf("%w: expected untrusted comment, signature, trusted comment and global signature lines", ErrMalformed)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil || len(data) != 2+8+ed25519.SignatureSize {
+		return nil, fmt.Errorf("%w: signature is not base64 encoded minisign signature", ErrMalformed)
+	}
+	global, err := base64.StdEncoding.DecodeString(lines[3])
+	if err != nil || len(global) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("%w: global signature is not base64 encoded minisign signature", ErrMalformed)
+	}
+
+	sig := &Signature{
+		Algorithm:       string(data[:2]),
+		Signature:       data[10:],
+		TrustedComment:  strings.TrimPrefix(lines[2], trustedCommentPrefix),
+		GlobalSignature: global,
+	}
+	copy(sig.KeyID[:], data[2:10])
+
+	if sig.Algorithm != algEd25519 && sig.Algorithm != algHashedEd25519 {
+		return nil, fmt.Errorf("%w: signature algorithm %q", errUnsupported, sig.Algorithm)
+	}
+	return sig, nil
+}
+
+// Verify checks that sig is a signature of message by key, including the trusted comment
+func Verify(key *PublicKey, message []byte, sig *Signature) error {
+	if sig.KeyID != key.ID {
+		return fmt.Errorf("%w: signature is by key %s, expected %s", ErrWrongKey, formatKeyID(sig.KeyID), key.KeyID())
+	}
+
+	signed := message
+	if sig.Algorithm == algHashedEd25519 {
+		sum := blake2b.Sum512(message)
+		signed = sum[:]
+	}
+	if !ed25519.Verify(key.Key, signed, sig.Signature) {
+		return ErrInvalid
+	}
+
+	// The global signature covers the trusted comment, so it cannot be swapped
+	global := append(bytes.Clone(sig.Signature), sig.TrustedComment...)
+	if !ed25519.Verify(key.Key, global, sig.GlobalSignature) {
+		return fmt.Errorf("%w: trusted comment does not match", ErrInvalid)
+	}
+
+	return nil
+}
+
+// formatKeyID formats a key ID like minisign, as the hex of a little endian number
+func formatKeyID(id [8]byte) string {
+	return fmt.Sprintf("%016X", binary.LittleEndian.Uint64(id[:]))
+}
+
+// nonEmptyLines splits s into lines, ignoring \r and empty lines
+func nonEmptyLines(s string) []string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimRight(line, "\r"); strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
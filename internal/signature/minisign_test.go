@@ -0,0 +1,188 @@
+package signature
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+var testKeyID = [8]byte{0xc1, 0xa2, 0xb3, 0xa4, 0xc1, 0xa1, 0xe4, 0xe6}
+
+// newTestKey returns a deterministic key pair with testKeyID
+func newTestKey(t *testing.T, seed byte) (*PublicKey, ed25519.PrivateKey) {
+	t.Helper()
+	priv := ed25519.NewKeyFromSeed([]byte(strings.Repeat(string(rune(seed)), ed25519.SeedSize)))
+	return &PublicKey{ID: testKeyID, Key: priv.Public().(ed25519.PublicKey)}, priv
+}
+
+// sign creates the content of a .minisig file for message, like `minisign -S` does
+func sign(priv ed25519.PrivateKey, id [8]byte, algorithm string, message []byte, trustedComment string) string {
+	signed := message
+	if algorithm == algHashedEd25519 {
+		sum := blake2b.Sum512(message)
+		signed = sum[:]
+	}
+	sig := ed25519.Sign(priv, signed)
+	global := ed25519.Sign(priv, append(append([]byte{}, sig...), trustedComment...))
+
+	data := append(append([]byte(algorithm), id[:]...), sig...)
+	return untrustedCommentPrefix + "signature from minisign secret key\n" +
+		base64.StdEncoding.EncodeToString(data) + "\n" +
+		trustedCommentPrefix + trustedComment + "\n" +
+		base64.StdEncoding.EncodeToString(global) + "\n"
+}
+
+func TestVerify(t *testing.T) {
+	key, priv := newTestKey(t, 1)
+	_, otherPriv := newTestKey(t, 2)
+	message := []byte("format = \"$all\"\n")
+	const comment = "timestamp:1700000000\tfile:config.toml"
+
+	tests := []struct {
+		name    string
+		sig     string
+		message []byte
+		want    error
+	}{
+		{"prehashed", sign(priv, testKeyID, algHashedEd25519, message, comment), message, nil},
+		{"legacy", sign(priv, testKeyID, algEd25519, message, comment), message, nil},
+		{"tampered message", sign(priv, testKeyID, algHashedEd25519, message, comment), []byte("format = \"$all$custom\"\n"), ErrInvalid},
+		{"tampered legacy message", sign(priv, testKeyID, algEd25519, message, comment), []byte("format = \"\"\n"), ErrInvalid},
+		{"tampered trusted comment", strings.Replace(sign(priv, testKeyID, algHashedEd25519, message, comment), "1700000000", "1800000000", 1), message, ErrInvalid},
+		{"different key", sign(otherPriv, testKeyID, algHashedEd25519, message, comment), message, ErrInvalid},
+		{"different key ID", sign(priv, [8]byte{1, 2, 3, 4, 5, 6, 7, 8}, algHashedEd25519, message, comment), message, ErrWrongKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sig, err := ParseSignature(tt.sig)
+			if err != nil {
+				t.Fatalf("ParseSignature() error = %v", err)
+			}
+			err = Verify(key, tt.message, sig)
+			if tt.want == nil && err != nil || tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifySwappedAlgorithm(t *testing.T) {
+	key, priv := newTestKey(t, 1)
+	message := []byte("add_newline = false\n")
+
+	// A prehashed signature relabeled as legacy must not verify
+	text := sign(priv, testKeyID, algHashedEd25519, message, "comment")
+	lines := strings.Split(text, "\n")
+	data, _ := base64.StdEncoding.DecodeString(lines[1])
+	copy(data, algEd25519)
+	lines[1] = base64.StdEncoding.EncodeToString(data)
+
+	sig, err := ParseSignature(strings.Join(lines, "\n"))
+	if err != nil {
+		t.Fatalf("ParseSignature() error = %v", err)
+	}
+	if err := Verify(key, message, sig); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Verify() error = %v, want %v", err, ErrInvalid)
+	}
+}
+
+func TestParseSignature(t *testing.T) {
+	_, priv := newTestKey(t, 1)
+	valid := sign(priv, testKeyID, algHashedEd25519, []byte("x"), "trusted")
+
+	sig, err := ParseSignature(strings.ReplaceAll(valid, "\n", "\r\n\n"))
+	if err != nil {
+		t.Fatalf("ParseSignature() with CRLF and blank lines error = %v", err)
+	}
+	if sig.Algorithm != algHashedEd25519 || sig.KeyID != testKeyID || sig.TrustedComment != "trusted" {
+		t.Errorf("ParseSignature() = %+v", sig)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(valid, "\n"), "\n")
+	badAlgorithm, _ := base64.StdEncoding.DecodeString(lines[1])
+	copy(badAlgorithm, "XX")
+
+	tests := []struct {
+		name string
+		in   string
+		want error
+	}{
+		{"empty", "", ErrMalformed},
+		{"missing global signature", strings.Join(lines[:3], "\n"), ErrMalformed},
+		{"missing untrusted comment", strings.Join(append([]string{"comment"}, lines[1:]...), "\n"), ErrMalformed},
+		{"missing trusted comment prefix", strings.Join([]string{lines[0], lines[1], "trusted", lines[3]}, "\n"), ErrMalformed},
+		{"invalid base64", strings.Join([]string{lines[0], "not base64!", lines[2], lines[3]}, "\n"), ErrMalformed},
+		{"truncated signature", strings.Join([]string{lines[0], lines[1][:40], lines[2], lines[3]}, "\n"), ErrMalformed},
+		{"truncated global signature", strings.Join([]string{lines[0], lines[1], lines[2], lines[3][:40]}, "\n"), ErrMalformed},
+		{"unsupported algorithm", strings.Join([]string{lines[0], base64.StdEncoding.EncodeToString(badAlgorithm), lines[2], lines[3]}, "\n"), errUnsupported},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseSignature(tt.in); !errors.Is(err, tt.want) {
+				t.Errorf("ParseSignature() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	key, _ := newTestKey(t, 1)
+	line := key.String()
+
+	for _, in := range []string{line, line + "\n", "untrusted comment: minisign public key E6E4A1C1A4B3A2C1\r\n" + line + "\r\n"} {
+		got, err := ParsePublicKey(in)
+		if err != nil {
+			t.Errorf("ParsePublicKey(%q) error = %v", in, err)
+			continue
+		}
+		if !got.Equal(key) {
+			t.Errorf("ParsePublicKey(%q) = %v, want %v", in, got, key)
+		}
+	}
+
+	data, _ := base64.StdEncoding.DecodeString(line)
+	copy(data, algHashedEd25519)
+
+	tests := []struct {
+		name string
+		in   string
+		want error
+	}{
+		{"empty", "", ErrMalformed},
+		{"two keys", line + "\n" + line, ErrMalformed},
+		{"invalid base64", "RWTB!", ErrMalformed},
+		{"truncated", line[:20], ErrMalformed},
+		{"unsupported algorithm", base64.StdEncoding.EncodeToString(data), errUnsupported},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParsePublicKey(tt.in); !errors.Is(err, tt.want) {
+				t.Errorf("ParsePublicKey() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyID(t *testing.T) {
+	key, _ := newTestKey(t, 1)
+
+	// minisign prints the ID as a little endian number
+	if got, want := key.KeyID(), "E6E4A1C1A4B3A2C1"; got != want {
+		t.Errorf("KeyID() = %q, want %q", got, want)
+	}
+
+	sig, err := ParseSignature(sign(ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize)), [8]byte{1}, algEd25519, nil, ""))
+	if err != nil {
+		t.Fatalf("ParseSignature() error = %v", err)
+	}
+	if err := Verify(key, nil, sig); err == nil || !strings.Contains(err.Error(), "0000000000000001") {
+		t.Errorf("Verify() error = %v, want it to name key 0000000000000001", err)
+	}
+}
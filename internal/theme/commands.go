@@ -0,0 +1,135 @@
+package theme
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultShell describes the interpreter of custom commands without a "shell" option
+const DefaultShell = "$STARSHIP_SHELL, or sh if unset"
+
+// Command is a config option that makes Starship run a process on every prompt
+type Command struct {
+	Module      string   // e.g. "custom.git_email" or "python"
+	Option      string   // e.g. "command", "when" or "python_binary"
+	Command     string   // The command exactly as written in the config
+	Interpreter string   // The shell the command runs in, or "" if it is executed directly
+	Position    Position // Where the option is defined
+}
+
+// extractCommands returns every command the config makes Starship run, in the order they appear.
+// This covers [custom.*] command/when/shell and the built-in module options that execute binaries.
+func extractCommands(config map[string]interface{}, source *sourceMap) []Command {
+	var commands []Command
+	add := func(module, option, command, interpreter string) {
+		if strings.TrimSpace(command) == "" {
+			return
+		}
+		commands = append(commands, Command{
+			Module:      module,
+			Option:      option,
+			Command:     command,
+			Interpreter: interpreter,
+			Position:    source.lookup(module + "." + option),
+		})
+	}
+
+	// [custom.<name>] modules run "command" and "when" in a shell
+	custom, _ := config["custom"].(map[string]interface{})
+	for name, value := range custom {
+		module, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		path := "custom." + name
+		interpreter := shellInterpreter(module["shell"])
+		if command, ok := module["command"].(string); ok {
+			add(path, "command", command, interpreter)
+		}
+		// "when" can also be a boolean, which runs nothing
+		if when, ok := module["when"].(string); ok {
+			add(path, "when", when, interpreter)
+		}
+	}
+
+	// Built-in modules that execute configurable binaries directly
+	if python, ok := config["python"].(map[string]interface{}); ok {
+		// A list of binaries, each either a name or a command with arguments
+		binaries := python["python_binary"]
+		if _, ok := binaries.(string); ok {
+			binaries = []interface{}{binaries}
+		}
+		items, _ := binaries.([]interface{})
+		for _, item := range items {
+			add("python", "python_binary", formatArgs(item), "")
+		}
+	}
+
+	for _, module := range []string{"c", "cpp"} {
+		options, ok := config[module].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		// Commands to try in order to get the compiler version, e.g. [["cc", "--version"]]
+		items, _ := options["commands"].([]interface{})
+		for _, item := range items {
+			add(module, "commands", formatArgs(item), "")
+		}
+	}
+
+	if gitStatus, ok := config["git_status"].(map[string]interface{}); ok {
+		// Path to a Windows starship binary used for repositories on a WSL path
+		if binary, ok := gitStatus["windows_starship"].(string); ok {
+			add("git_status", "windows_starship", binary, "")
+		}
+	}
+
+	sort.SliceStable(commands, func(i, j int) bool {
+		a, b := commands[i].Position, commands[j].Position
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+
+	return commands
+}
+
+// shellInterpreter describes the "shell" option of a custom module
+func shellInterpreter(value interface{}) string {
+	switch shell := value.(type) {
+	case string:
+		if shell != "" {
+			return shell
+		}
+	case []interface{}:
+		if len(shell) > 0 {
+			return formatArgs(shell)
+		}
+	}
+	return DefaultShell
+}
+
+// formatArgs turns a command given as a string or an array of arguments into a single line,
+// quoting arguments that contain whitespace
+func formatArgs(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []interface{}:
+		args := make([]string, len(v))
+		for i, arg := range v {
+			s := fmt.Sprint(arg)
+			if s == "" || strings.ContainsAny(s, " \t\n\"'") {
+				s = strconv.Quote(s)
+			}
+			args[i] = s
+		}
+		return strings.Join(args, " ")
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,212 @@
+package theme
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// constraintPattern matches the characters allowed in a version range like "^1.2", "~1" or ">=1.0 <2.0"
+const constraintPattern = `[\^~<>=*][\^~<>=*0-9A-Za-z.+|, -]*`
+
+// partialVersionRegex matches a possibly incomplete version like "1", "1.2" or "1.2.3-beta.1"
+var partialVersionRegex = regexp.MustCompile(`^v?(` + numberPattern + `)(?:\.(` + numberPattern + `))?(?:\.(` + numberPattern + `))?(?:-(` + prereleasePattern + `))?$`)
+
+// Constraint is a version range in npm/cargo style.
+// Comparators separated by spaces or commas must all match, "||" separates alternatives.
+type Constraint struct {
+	raw  string
+	sets [][]comparator
+}
+
+type comparator struct {
+	op      string // One of "=", ">", ">=", "<", "<="
+	version Version
+}
+
+// ParseConstraint parses a version range such as "^1.2", "~1", ">=1.0 <2.0" or "^1.0 || ^2.0"
+func ParseConstraint(s string) (*Constraint, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, fmt.Errorf("empty version constraint")
+	}
+
+	c := &Constraint{raw: s}
+	for _, alternative := range strings.Split(s, "||") {
+		fields := strings.FieldsFunc(alternative, func(r rune) bool {
+			return r == ' ' || r == ','
+		})
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("invalid version constraint: %s", s)
+		}
+
+		var set []comparator
+		for _, field := range fields {
+			comparators, err := parseComparator(field)
+			if err != nil {
+				return nil, fmt.Errorf("invalid version constraint %q: %w", s, err)
+			}
+			set = append(set, comparators...)
+		}
+		c.sets = append(c.sets, set)
+	}
+
+	return c, nil
+}
+
+// parseComparator expands one term of a constraint into plain comparators
+func parseComparator(term string) ([]comparator, error) {
+	if term == "*" {
+		return []comparator{{op: ">=", version: Version{}}}, nil
+	}
+
+	op := ""
+	for _, prefix := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(term, prefix) {
+			op = prefix
+			term = strings.TrimPrefix(term, prefix)
+			break
+		}
+	}
+
+	version, parts, err := parsePartialVersion(term)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case "^":
+		// Allow changes that do not modify the left-most non-zero component
+		upper := Version{Major: version.Major + 1}
+		if version.Major == 0 && parts > 1 {
+			upper = Version{Minor: version.Minor + 1}
+			if version.Minor == 0 && parts > 2 {
+				upper = Version{Patch: version.Patch + 1}
+			}
+		}
+		return []comparator{{">=", version}, {"<", upper.lowest()}}, nil
+	case "~":
+		// Allow patch changes if a minor version is given, minor changes otherwise
+		upper := Version{Major: version.Major + 1}
+		if parts > 1 {
+			upper = Version{Major: version.Major, Minor: version.Minor + 1}
+		}
+		return []comparator{{">=", version}, {"<", upper.lowest()}}, nil
+	case "", "=":
+		// A partial version matches everything it leaves open, e.g. "1.2" matches 1.2.x
+		if parts == 3 || version.IsPrerelease() {
+			return []comparator{{"=", version}}, nil
+		}
+		upper := Version{Major: version.Major + 1}
+		if parts == 2 {
+			upper = Version{Major: version.Major, Minor: version.Minor + 1}
+		}
+		return []comparator{{">=", version}, {"<", upper.lowest()}}, nil
+	default:
+		return []comparator{{op, version}}, nil
+	}
+}
+
+// parsePartialVersion parses "1", "1.2" or "1.2.3[-pre]" and returns how many components were given
+func parsePartialVersion(s string) (Version, int, error) {
+	matches := partialVersionRegex.FindStringSubmatch(s)
+	if matches == nil {
+		return Version{}, 0, fmt.Errorf("invalid version: %q", s)
+	}
+
+	var version Version
+	parts := 0
+	for i, target := range []*int{&version.Major, &version.Minor, &version.Patch} {
+		if matches[i+1] == "" {
+			break
+		}
+		n, err := strconv.Atoi(matches[i+1])
+		if err != nil {
+			return Version{}, 0, fmt.Errorf("invalid version: %q", s)
+		}
+		*target = n
+		parts++
+	}
+
+	if matches[4] != "" {
+		version.Prerelease = strings.Split(matches[4], ".")
+	}
+
+	return version, parts, nil
+}
+
+// lowest returns the lowest possible version with the same major.minor.patch,
+// so "<2.0.0" also excludes prereleases like "2.0.0-beta"
+func (v Version) lowest() Version {
+	v.Prerelease = []string{"0"}
+	return v
+}
+
+func (c comparator) matches(v Version) bool {
+	cmp := v.Compare(c.version)
+	switch c.op {
+	case "=":
+		return cmp == 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+	return false
+}
+
+// Matches reports whether version satisfies the constraint.
+// Prereleases only match if the constraint mentions a prerelease of the same major.minor.patch.
+func (c *Constraint) Matches(version string) bool {
+	v, ok := ParseVersion(version)
+	if !ok {
+		return false
+	}
+
+	for _, set := range c.sets {
+		if setMatches(set, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func setMatches(set []comparator, v Version) bool {
+	allowPrerelease := !v.IsPrerelease()
+	for _, comp := range set {
+		if !comp.matches(v) {
+			return false
+		}
+
+		cv := comp.version
+		if len(cv.Prerelease) > 0 && cv.Prerelease[0] != "0" &&
+			cv.Major == v.Major && cv.Minor == v.Minor && cv.Patch == v.Patch {
+			allowPrerelease = true
+		}
+	}
+	return allowPrerelease
+}
+
+// Highest returns the highest version in versions that satisfies the constraint
+func (c *Constraint) Highest(versions []string) (string, bool) {
+	best := ""
+	for _, v := range versions {
+		if !c.Matches(v) {
+			continue
+		}
+		if best == "" || CompareVersions(v, best) > 0 {
+			best = v
+		}
+	}
+	return best, best != ""
+}
+
+// String returns the constraint as it was written
+func (c *Constraint) String() string {
+	return c.raw
+}
@@ -0,0 +1,113 @@
+package theme
+
+import "testing"
+
+func TestConstraintMatches(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		want       bool
+	}{
+		// Caret allows changes that keep the left-most non-zero component
+		{"^1.2", "1.2.0", true},
+		{"^1.2", "1.9.3", true},
+		{"^1.2", "1.1.9", false},
+		{"^1.2", "2.0.0", false},
+		{"^1", "1.99", true},
+
+		// 0.x versions are treated as breaking on every minor, 0.0.x on every patch
+		{"^0.2", "0.2.5", true},
+		{"^0.2", "0.3.0", false},
+		{"^0.2.3", "0.2.9", true},
+		{"^0.2.3", "0.2.2", false},
+		{"^0.0.3", "0.0.3", true},
+		{"^0.0.3", "0.0.4", false},
+		{"^0.0", "0.0.9", true},
+		{"^0.0", "0.1.0", false},
+		{"^0", "0.9.9", true},
+		{"^0", "1.0.0", false},
+
+		// Tilde allows patch changes if a minor is given, minor changes otherwise
+		{"~1.2", "1.2.9", true},
+		{"~1.2", "1.3.0", false},
+		{"~1", "1.9", true},
+		{"~1", "2.0", false},
+		{"~0.2", "0.2.1", true},
+		{"~0.2", "0.3.0", false},
+
+		// Plain and partial versions
+		{"1.2", "1.2.7", true},
+		{"1.2", "1.3", false},
+		{"=1.2.3", "1.2.3", true},
+		{"1.2.3", "1.2.4", false},
+		{"v1", "1.4", true},
+		{"*", "0.0.1", true},
+
+		// Ranges and alternatives
+		{">=1.0 <2.0", "1.5", true},
+		{">=1.0 <2.0", "2.0", false},
+		{">=1.0, <2.0", "0.9", false},
+		{">1.0", "1.0", false},
+		{"<=1.0", "1.0", true},
+		{"^1.0 || ^3.0", "3.1", true},
+		{"^1.0 || ^3.0", "2.1", false},
+
+		// Prereleases only match if the constraint mentions one of the same version
+		{"^1.0", "1.5.0-beta", false},
+		{"<2.0", "2.0.0-beta", false},
+		{">=2.0.0-beta", "2.0.0-rc.1", true},
+		{">=2.0.0-beta", "2.0.0", true},
+		{">=2.0.0-beta", "2.1.0-beta", false},
+		{"^2.0.0-beta.2", "2.0.0-beta.1", false},
+		{"2.0.0-rc.1", "2.0.0-rc.1", true},
+
+		{"^1.0", "latest", false},
+	}
+
+	for _, tt := range tests {
+		c, err := ParseConstraint(tt.constraint)
+		if err != nil {
+			t.Errorf("ParseConstraint(%q) error = %v", tt.constraint, err)
+			continue
+		}
+		if got := c.Matches(tt.version); got != tt.want {
+			t.Errorf("%q.Matches(%q) = %v, want %v", tt.constraint, tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestParseConstraintErrors(t *testing.T) {
+	for _, s := range []string{"", "  ", "^", "^1.x", ">=01.0", "1.2.3.4", "^1.0 ||", ">=1.0 <"} {
+		if _, err := ParseConstraint(s); err == nil {
+			t.Errorf("ParseConstraint(%q) succeeded, want an error", s)
+		}
+	}
+}
+
+func TestConstraintHighest(t *testing.T) {
+	versions := []string{"0.9", "1.0", "1.2", "1.10", "2.0-beta", "2.0", "latest"}
+
+	tests := []struct {
+		constraint string
+		want       string
+		ok         bool
+	}{
+		{"^1.0", "1.10", true},
+		{"~1.2", "1.2", true},
+		{"<2.0", "1.10", true},
+		{"*", "2.0", true},
+		{"^0.9", "0.9", true},
+		{"^3.0", "", false},
+	}
+
+	for _, tt := range tests {
+		c, err := ParseConstraint(tt.constraint)
+		if err != nil {
+			t.Fatalf("ParseConstraint(%q) error = %v", tt.constraint, err)
+		}
+		got, ok := c.Highest(versions)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%q.Highest() = %q, %v, want %q, %v", tt.constraint, got, ok, tt.want, tt.ok)
+		}
+	}
+}
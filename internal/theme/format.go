@@ -0,0 +1,193 @@
+package theme
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FormatString is a parsed Starship format string like "[$symbol$branch]($style) "
+type FormatString struct {
+	Variables []FormatVariable // Every $variable, in order of appearance
+	Styles    []FormatStyle    // The style of every [text](style) group, in order of appearance
+}
+
+// FormatVariable is a $name or ${scoped.name} reference in a format string
+type FormatVariable struct {
+	Name   string // Without "$" and braces, e.g. "git_branch" or "custom.foo"
+	Offset int    // Byte offset of the "$"
+}
+
+// FormatStyle is the style part of a [text](style) group
+type FormatStyle struct {
+	Style  string
+	Offset int // Byte offset of the first character after "("
+}
+
+// SyntaxError is a problem at a specific byte offset in a format or style string
+type SyntaxError struct {
+	Offset  int
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s (at offset %d)", e.Message, e.Offset)
+}
+
+// ParseFormat parses a Starship format string.
+// The grammar is: text, $variable, ${scoped.variable}, [format](style) groups and (format) conditional groups,
+// where "[", "]", "(", ")", "$" and "\" must be escaped with "\" to be used as text.
+func ParseFormat(s string) (*FormatString, error) {
+	p := &formatParser{input: s, result: &FormatString{}}
+	if err := p.parseSequence(0); err != nil {
+		return nil, err
+	}
+	return p.result, nil
+}
+
+type formatParser struct {
+	input  string
+	pos    int
+	result *FormatString
+}
+
+// openGroup is a "[" or "(" group the parser is currently inside of
+type openGroup struct {
+	char   byte // '[' or '('
+	offset int
+}
+
+// parseSequence parses values until the end of the input or the closing bracket of the current group
+func (p *formatParser) parseSequence(depth int) error {
+	var group *openGroup
+	if depth > 0 {
+		group = &openGroup{char: p.input[p.pos-1], offset: p.pos - 1}
+	}
+
+	for p.pos < len(p.input) {
+		switch c := p.input[p.pos]; c {
+		case '\\':
+			if p.pos+1 >= len(p.input) || !strings.ContainsRune(`[]()$\`, rune(p.input[p.pos+1])) {
+				return &SyntaxError{Offset: p.pos, Message: `invalid escape, only \[ \] \( \) \$ and \\ are allowed`}
+			}
+			p.pos += 2
+
+		case '$':
+			if err := p.parseVariable(); err != nil {
+				return err
+			}
+
+		case '[', '(':
+			p.pos++
+			if err := p.parseSequence(depth + 1); err != nil {
+				return err
+			}
+			if c == '[' {
+				if err := p.parseStyle(); err != nil {
+					return err
+				}
+			}
+
+		case ']', ')':
+			if group == nil {
+				return &SyntaxError{Offset: p.pos, Message: fmt.Sprintf("unexpected %q without matching %q", string(c), string(opening(c)))}
+			}
+			if c != closing(group.char) {
+				return &SyntaxError{Offset: p.pos, Message: fmt.Sprintf("unexpected %q, expected %q", string(c), string(closing(group.char)))}
+			}
+			p.pos++
+			return nil
+
+		default:
+			p.pos++
+		}
+	}
+
+	if group != nil {
+		return &SyntaxError{Offset: group.offset, Message: fmt.Sprintf("unclosed %q", string(group.char))}
+	}
+	return nil
+}
+
+// parseVariable parses $name or ${scoped.name} at the current position
+func (p *formatParser) parseVariable() error {
+	start := p.pos
+	p.pos++ // $
+
+	if p.pos < len(p.input) && p.input[p.pos] == '{' {
+		end := strings.IndexByte(p.input[p.pos:], '}')
+		if end < 0 {
+			return &SyntaxError{Offset: start, Message: `unclosed "${"`}
+		}
+		name := p.input[p.pos+1 : p.pos+end]
+		for _, part := range strings.Split(name, ".") {
+			if part == "" || strings.IndexFunc(part, isNotVariableChar) >= 0 {
+				return &SyntaxError{Offset: start, Message: fmt.Sprintf("invalid variable name %q", name)}
+			}
+		}
+		p.pos += end + 1
+		p.result.Variables = append(p.result.Variables, FormatVariable{Name: name, Offset: start})
+		return nil
+	}
+
+	end := p.pos
+	for end < len(p.input) && !isNotVariableChar(rune(p.input[end])) {
+		end++
+	}
+	if end == p.pos {
+		return &SyntaxError{Offset: start, Message: `"$" must be followed by a variable name, use \$ for a literal "$"`}
+	}
+	p.result.Variables = append(p.result.Variables, FormatVariable{Name: p.input[p.pos:end], Offset: start})
+	p.pos = end
+	return nil
+}
+
+// parseStyle parses the (style) that must follow the "]" of a text group
+func (p *formatParser) parseStyle() error {
+	if p.pos >= len(p.input) || p.input[p.pos] != '(' {
+		return &SyntaxError{Offset: p.pos - 1, Message: `text group "[...]" must be followed by a style "(...)"`}
+	}
+	start := p.pos
+	p.pos++
+
+	// Styles can contain variables like $style, but no nested groups
+	var style strings.Builder
+	for p.pos < len(p.input) {
+		switch c := p.input[p.pos]; c {
+		case ')':
+			p.result.Styles = append(p.result.Styles, FormatStyle{Style: style.String(), Offset: start + 1})
+			p.pos++
+			return nil
+		case '$':
+			varStart := p.pos
+			if err := p.parseVariable(); err != nil {
+				return err
+			}
+			style.WriteString(p.input[varStart:p.pos])
+		case '[', ']', '(':
+			return &SyntaxError{Offset: p.pos, Message: fmt.Sprintf("unexpected %q in style", string(c))}
+		default:
+			style.WriteByte(c)
+			p.pos++
+		}
+	}
+
+	return &SyntaxError{Offset: start, Message: `unclosed "(" of style`}
+}
+
+func isNotVariableChar(r rune) bool {
+	return !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+}
+
+func opening(c byte) byte {
+	if c == ']' {
+		return '['
+	}
+	return '('
+}
+
+func closing(c byte) byte {
+	if c == '[' {
+		return ']'
+	}
+	return ')'
+}
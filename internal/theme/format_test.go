@@ -0,0 +1,75 @@
+package theme
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		in        string
+		variables []FormatVariable
+		styles    []FormatStyle
+	}{
+		{"", nil, nil},
+		{"plain text", nil, nil},
+		{"$all", []FormatVariable{{"all", 0}}, nil},
+		{"$directory$git_branch ", []FormatVariable{{"directory", 0}, {"git_branch", 10}}, nil},
+		{"${custom.foo}$character", []FormatVariable{{"custom.foo", 0}, {"character", 13}}, nil},
+		{"[$symbol$branch]($style) ", []FormatVariable{{"symbol", 1}, {"branch", 8}, {"style", 17}}, []FormatStyle{{"$style", 17}}},
+		{"[on](bold red)", nil, []FormatStyle{{"bold red", 5}}},
+		{"($git_status )", []FormatVariable{{"git_status", 1}}, nil},
+		{"[[a](red)b](blue)", nil, []FormatStyle{{"red", 5}, {"blue", 12}}},
+		{`\$5 \[x\] \(y\) \\`, nil, nil},
+		{"[](fg:#89b4fa)", nil, []FormatStyle{{"fg:#89b4fa", 3}}},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseFormat(tt.in)
+		if err != nil {
+			t.Errorf("ParseFormat(%q) error = %v", tt.in, err)
+			continue
+		}
+		if !slices.Equal(got.Variables, tt.variables) {
+			t.Errorf("ParseFormat(%q).Variables = %+v, want %+v", tt.in, got.Variables, tt.variables)
+		}
+		if !slices.Equal(got.Styles, tt.styles) {
+			t.Errorf("ParseFormat(%q).Styles = %+v, want %+v", tt.in, got.Styles, tt.styles)
+		}
+	}
+}
+
+func TestParseFormatErrors(t *testing.T) {
+	tests := []struct {
+		in     string
+		offset int
+	}{
+		{"[$symbol", 0},
+		{"abc]", 3},
+		{"($a]", 3},
+		{"[x]", 2},
+		{"[x] (red)", 2},
+		{"[x](red", 3},
+		{"[x](r[ed)", 5},
+		{"$", 0},
+		{"a $ b", 2},
+		{"${custom.foo", 0},
+		{"${custom..foo}", 0},
+		{"${}", 0},
+		{`\n`, 0},
+		{`abc\`, 3},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseFormat(tt.in)
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("ParseFormat(%q) error = %v, want a *SyntaxError", tt.in, err)
+			continue
+		}
+		if syntaxErr.Offset != tt.offset {
+			t.Errorf("ParseFormat(%q) error at offset %d, want %d (%v)", tt.in, syntaxErr.Offset, tt.offset, err)
+		}
+	}
+}
@@ -6,26 +6,29 @@ import (
 	"path/filepath"
 	"regexp"
 	"sort"
-	"strconv"
 	"strings"
 )
 
 type Theme struct {
 	Author          string
 	Name            string
-	Version         string // Optional, defaults to "latest"
-	VersionExplicit bool   // True if version was explicitly specified in the identifier
+	Version         string      // Optional, defaults to "latest"
+	VersionExplicit bool        // True if version was explicitly specified in the identifier
+	Constraint      *Constraint // Set if the identifier used a version range like "^1.2", Version stays "latest" until resolved
 }
 
-// ParseIdentifier parses "alice/rainbow@1.2", "alice/rainbow@latest", or "alice/rainbow"
+// identifierRegex matches author/name[@version] and author/name@constraint
+// Version can be a semantic version (e.g., "1.2", "v1.2", "2.1.3", "3.0-beta.1") or "latest",
+// constraint a version range (e.g., "^1.2", "~1", ">=1.0 <2.0")
+var identifierRegex = regexp.MustCompile(`^([a-zA-Z0-9_-]+)/([a-zA-Z0-9_-]+)(?:@(?:v?(` + versionPattern + `|latest)|(` + constraintPattern + `)))?$`)
+
+// ParseIdentifier parses "alice/rainbow@1.2", "alice/rainbow@2.1.3", "alice/rainbow@latest",
+// "alice/rainbow@^1.2", or "alice/rainbow"
 func ParseIdentifier(identifier string) (*Theme, error) {
 	// Normalize: remove leading/trailing whitespace
 	identifier = strings.TrimSpace(identifier)
 
-	// Match pattern: author/name[@version]
-	// Version can be numeric (e.g., "1.2", "v1.2") or "latest"
-	re := regexp.MustCompile(`^([a-zA-Z0-9_-]+)/([a-zA-Z0-9_-]+)(?:@v?([0-9]+\.[0-9]+|latest))?$`)
-	matches := re.FindStringSubmatch(identifier)
+	matches := identifierRegex.FindStringSubmatch(identifier)
 
 	if matches == nil {
 		return nil, fmt.Errorf("invalid theme identifier: %s (expected format: author/theme[@version])", identifier)
@@ -42,6 +45,14 @@ func ParseIdentifier(identifier string) (*Theme, error) {
 		theme.Version = matches[3]
 	}
 
+	if matches[4] != "" {
+		constraint, err := ParseConstraint(matches[4])
+		if err != nil {
+			return nil, err
+		}
+		theme.Constraint = constraint
+	}
+
 	return theme, nil
 }
 
@@ -52,20 +63,57 @@ func (t *Theme) String() string {
 	return fmt.Sprintf("%s/%s@%s", t.Author, t.Name, t.Version)
 }
 
+// CachePath returns the path of the cached .toml file for this theme version.
+// If the exact file does not exist but an equivalent version does (e.g. "1.2.toml" for "1.2.0"),
+// the existing file is used, so caches from before patch versions keep working.
 func (t *Theme) CachePath() (string, error) {
-	home, err := os.UserHomeDir()
+	themeDir, err := t.CacheDir()
 	if err != nil {
 		return "", err
 	}
 
-	return filepath.Join(
-		home,
-		".config",
-		"stellar",
-		t.Author,
-		t.Name,
-		t.Version+".toml",
-	), nil
+	if !isPathComponent(t.Version) {
+		return "", fmt.Errorf("invalid version %q for %s/%s: cannot be used as a file name", t.Version, t.Author, t.Name)
+	}
+	path := filepath.Join(themeDir, t.Version+".toml")
+	if err := checkInside(themeDir, path); err != nil {
+		return "", err
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	if equivalent, ok := findEquivalentVersion(themeDir, t.Version); ok {
+		return filepath.Join(themeDir, equivalent+".toml"), nil
+	}
+
+	return path, nil
+}
+
+// findEquivalentVersion looks for a cached version with the same precedence as version
+func findEquivalentVersion(themeDir, version string) (string, bool) {
+	want, ok := ParseVersion(version)
+	if !ok {
+		return "", false
+	}
+
+	entries, err := os.ReadDir(themeDir)
+	if err != nil {
+		return "", false
+	}
+
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".toml") {
+			continue
+		}
+		ver := strings.TrimSuffix(e.Name(), ".toml")
+		if have, ok := ParseVersion(ver); ok && have.Compare(want) == 0 {
+			return ver, true
+		}
+	}
+
+	return "", false
 }
 
 // CacheDir returns the directory path for this theme (without version file)
@@ -75,22 +123,41 @@ func (t *Theme) CacheDir() (string, error) {
 		return "", err
 	}
 
-	return filepath.Join(
-		home,
-		".config",
-		"stellar",
-		t.Author,
-		t.Name,
-	), nil
+	if !isPathComponent(t.Author) || !isPathComponent(t.Name) {
+		return "", fmt.Errorf("invalid theme %q: cannot be used as a file name", t.Author+"/"+t.Name)
+	}
+
+	stellarDir := filepath.Join(home, ".config", "stellar")
+	themeDir := filepath.Join(stellarDir, t.Author, t.Name)
+	if err := checkInside(stellarDir, themeDir); err != nil {
+		return "", err
+	}
+
+	return themeDir, nil
 }
 
-// FindLatestLocalVersion scans a theme directory and returns the highest semver version found.
-// Falls back to "latest" if only latest.toml exists (backward compatibility).
+// isPathComponent reports whether s can be used as a single file or directory name in the cache.
+// Hidden names are reserved for stellar's own directories, like .cache.
+func isPathComponent(s string) bool {
+	return s != "" && !strings.HasPrefix(s, ".") && !strings.ContainsAny(s, `/\:`) && filepath.Base(s) == s
+}
+
+// checkInside returns an error unless path is below dir, so no value from a hub response
+// or identifier can make stellar read or write files elsewhere
+func checkInside(dir, path string) error {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || filepath.IsAbs(rel) {
+		return fmt.Errorf("path %s is outside of %s", path, dir)
+	}
+	return nil
+}
+
+// ListLocalVersions returns all versions cached in a theme directory, in no particular order.
 // Returns error if no .toml files are found.
-func FindLatestLocalVersion(themeDir string) (string, error) {
+func ListLocalVersions(themeDir string) ([]string, error) {
 	entries, err := os.ReadDir(themeDir)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	var versions []string
@@ -102,23 +169,50 @@ func FindLatestLocalVersion(themeDir string) (string, error) {
 	}
 
 	if len(versions) == 0 {
-		return "", fmt.Errorf("no versions found in %s", themeDir)
+		return nil, fmt.Errorf("no versions found in %s", themeDir)
+	}
+
+	return versions, nil
+}
+
+// FindLatestLocalMatch returns the highest cached version that satisfies the constraint
+func FindLatestLocalMatch(themeDir string, constraint *Constraint) (string, error) {
+	versions, err := ListLocalVersions(themeDir)
+	if err != nil {
+		return "", err
+	}
+
+	version, ok := constraint.Highest(versions)
+	if !ok {
+		return "", fmt.Errorf("no cached version in %s matches %s", themeDir, constraint)
+	}
+
+	return version, nil
+}
+
+// FindLatestLocalVersion scans a theme directory and returns the highest semver version found.
+// Falls back to "latest" if only latest.toml exists (backward compatibility).
+// Returns error if no .toml files are found.
+func FindLatestLocalVersion(themeDir string) (string, error) {
+	versions, err := ListLocalVersions(themeDir)
+	if err != nil {
+		return "", err
 	}
 
 	// Sort by semver descending, "latest" goes last as fallback
 	sort.Slice(versions, func(i, j int) bool {
-		return compareSemver(versions[i], versions[j]) > 0
+		return CompareVersions(versions[i], versions[j]) > 0
 	})
 
 	return versions[0], nil
 }
 
-// compareSemver compares two version strings.
-// Returns >0 if a > b, <0 if a < b, 0 if equal.
-// Non-numeric versions (like "latest") are sorted to the end.
-func compareSemver(a, b string) int {
-	aMajor, aMinor, aOk := parseSemver(a)
-	bMajor, bMinor, bOk := parseSemver(b)
+// CompareVersions compares two version strings using semantic version precedence.
+// Returns >0 if a > b, <0 if a < b, 0 if equal (so "1.2" equals "1.2.0").
+// Non-semver versions (like "latest") are sorted to the end.
+func CompareVersions(a, b string) int {
+	aVer, aOk := ParseVersion(a)
+	bVer, bOk := ParseVersion(b)
 
 	// Non-semver versions go to the end
 	if !aOk && !bOk {
@@ -131,31 +225,5 @@ func compareSemver(a, b string) int {
 		return 1 // b goes after a
 	}
 
-	// Compare major version
-	if aMajor != bMajor {
-		return aMajor - bMajor
-	}
-	// Compare minor version
-	return aMinor - bMinor
-}
-
-// parseSemver parses a version string like "1.2" into major and minor components.
-// Returns false if the string is not a valid semver.
-func parseSemver(v string) (major, minor int, ok bool) {
-	parts := strings.Split(v, ".")
-	if len(parts) != 2 {
-		return 0, 0, false
-	}
-
-	major, err := strconv.Atoi(parts[0])
-	if err != nil {
-		return 0, 0, false
-	}
-
-	minor, err = strconv.Atoi(parts[1])
-	if err != nil {
-		return 0, 0, false
-	}
-
-	return major, minor, true
+	return aVer.Compare(bVer)
 }
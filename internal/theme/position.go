@@ -0,0 +1,282 @@
+package theme
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Position is a 1-based line and column in a config file
+type Position struct {
+	Line   int
+	Column int
+}
+
+// sourceMap records where keys and string values are defined in a TOML document.
+// Paths are dotted keys, e.g. "git_branch" or "git_branch.format".
+type sourceMap struct {
+	keys    map[string]Position
+	strings map[string]stringSource
+}
+
+// stringSource is the raw text of a string value as written in the document
+type stringSource struct {
+	start     Position // Position of the first character after the opening quotes
+	raw       string
+	literal   bool // Single quoted, no escapes
+	multiline bool
+}
+
+// mapSource finds the position of every table header, key and string value in a TOML document.
+// It is not a full TOML parser, it only needs to work for documents that already decoded successfully.
+func mapSource(content string) *sourceMap {
+	m := &sourceMap{
+		keys:    make(map[string]Position),
+		strings: make(map[string]stringSource),
+	}
+	table := ""
+	depth := 0 // Nesting of a multi-line array or inline table value
+	lines := strings.Split(content, "\n")
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		lineNum := i + 1
+
+		trimmed := strings.TrimLeft(line, " \t")
+		column := len(line) - len(trimmed) + 1
+		if depth > 0 {
+			// Elements like `  ["a", "b"],` continue the value, they are no table headers
+			depth = bracketDepth(line, depth)
+			continue
+		}
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		// Table headers: [table] and [[array.of.tables]]
+		if strings.HasPrefix(trimmed, "[") {
+			header := strings.TrimLeft(trimmed, "[")
+			if end := strings.Index(header, "]"); end >= 0 {
+				table = normalizeKey(header[:end])
+				if _, ok := m.keys[table]; !ok {
+					m.keys[table] = Position{Line: lineNum, Column: column}
+				}
+			}
+			continue
+		}
+
+		// Key/value pairs: key = value
+		eq := keyValueSeparator(trimmed)
+		if eq < 0 {
+			continue
+		}
+
+		key := normalizeKey(trimmed[:eq])
+		if table != "" {
+			key = table + "." + key
+		}
+		if _, ok := m.keys[key]; !ok {
+			m.keys[key] = Position{Line: lineNum, Column: column}
+		}
+
+		// Remember where string values start, so problems inside them can be pinpointed
+		valueOffset := len(line) - len(strings.TrimLeft(trimmed[eq+1:], " \t"))
+		value := line[valueOffset:]
+		depth = bracketDepth(value, 0)
+		valueColumn := utf8.RuneCountInString(line[:valueOffset]) + 1
+
+		for _, delim := range []string{`"""`, `'''`, `"`, `'`} {
+			if !strings.HasPrefix(value, delim) {
+				continue
+			}
+
+			src := stringSource{
+				start:     Position{Line: lineNum, Column: valueColumn + len(delim)},
+				literal:   delim[0] == '\'',
+				multiline: len(delim) == 3,
+			}
+			rest := value[len(delim):]
+
+			if !src.multiline {
+				src.raw = rest[:closingQuote(rest, delim[0], src.literal)]
+			} else {
+				// Multi-line strings continue until the closing delimiter, possibly lines later
+				var raw strings.Builder
+				for {
+					if end := strings.Index(rest, delim); end >= 0 {
+						raw.WriteString(rest[:end])
+						break
+					}
+					raw.WriteString(rest)
+					if i+1 >= len(lines) {
+						break
+					}
+					raw.WriteString("\n")
+					i++
+					rest = lines[i]
+				}
+				src.raw = raw.String()
+			}
+
+			if _, ok := m.strings[key]; !ok {
+				m.strings[key] = src
+			}
+			break
+		}
+	}
+
+	return m
+}
+
+// closingQuote returns the index of the quote ending a single-line string, or len(s) if there is none
+func closingQuote(s string, quote byte, literal bool) int {
+	for i := 0; i < len(s); i++ {
+		switch {
+		case !literal && s[i] == '\\':
+			i++
+		case s[i] == quote:
+			return i
+		}
+	}
+	return len(s)
+}
+
+// keyValueSeparator returns the index of the "=" separating key and value, ignoring "=" in quoted keys
+func keyValueSeparator(line string) int {
+	quote := rune(0)
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '=':
+			return i
+		}
+	}
+	return -1
+}
+
+// bracketDepth returns the nesting of arrays and inline tables after s, starting at depth.
+// Brackets in strings and comments don't count. Values nested deeper than 0 continue on the next line,
+// and lines inside them are not table headers even if they start with "[".
+func bracketDepth(s string, depth int) int {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '#':
+			return depth
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth = max(depth-1, 0)
+		case '"', '\'':
+			delim := string(c)
+			if strings.HasPrefix(s[i:], strings.Repeat(delim, 3)) {
+				delim = strings.Repeat(delim, 3)
+			}
+			rest := s[i+len(delim):]
+			end := len(rest)
+			if len(delim) == 3 {
+				if e := strings.Index(rest, delim); e >= 0 {
+					end = e
+				}
+			} else {
+				end = closingQuote(rest, c, c == '\'')
+			}
+			i += len(delim) + end + len(delim) - 1
+		}
+	}
+	return depth
+}
+
+// normalizeKey turns a TOML key like ` custom . "my-module" ` into "custom.my-module"
+func normalizeKey(key string) string {
+	parts := strings.Split(key, ".")
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		part = strings.Trim(part, `"'`)
+		parts[i] = part
+	}
+	return strings.Join(parts, ".")
+}
+
+// lookup returns the position of path, falling back to its closest defined parent
+func (m *sourceMap) lookup(path string) Position {
+	for path != "" {
+		if pos, ok := m.keys[path]; ok {
+			return pos
+		}
+		dot := strings.LastIndex(path, ".")
+		if dot < 0 {
+			break
+		}
+		path = path[:dot]
+	}
+	return Position{}
+}
+
+// valuePosition returns the position of the byte at offset in the decoded string value of path.
+// Falls back to the position of the key if the value could not be located.
+func (m *sourceMap) valuePosition(path string, offset int) Position {
+	src, ok := m.strings[path]
+	if !ok {
+		return m.lookup(path)
+	}
+
+	pos := src.start
+	raw := src.raw
+
+	// A newline right after the opening delimiter is not part of the value
+	if src.multiline && strings.HasPrefix(raw, "\n") {
+		raw = raw[1:]
+		pos = Position{Line: pos.Line + 1, Column: 1}
+	}
+
+	decoded := 0
+	for i := 0; i < len(raw) && decoded < offset; {
+		r, size := utf8.DecodeRuneInString(raw[i:])
+
+		if r == '\\' && !src.literal && i+1 < len(raw) {
+			switch next := raw[i+1]; next {
+			case 'u', 'U':
+				digits := 4
+				if next == 'U' {
+					digits = 8
+				}
+				end := min(i+2+digits, len(raw))
+				code, _ := strconv.ParseUint(raw[i+2:end], 16, 32)
+				decoded += max(1, utf8.RuneLen(rune(code)))
+				pos.Column += end - i
+				i = end
+			case ' ', '\t', '\n':
+				// Line ending backslash: all following whitespace is trimmed
+				i++
+				for i < len(raw) && strings.ContainsRune(" \t\n", rune(raw[i])) {
+					if raw[i] == '\n' {
+						pos = Position{Line: pos.Line + 1, Column: 1}
+					} else {
+						pos.Column++
+					}
+					i++
+				}
+			default:
+				decoded++
+				pos.Column += 2
+				i += 2
+			}
+			continue
+		}
+
+		if r == '\n' {
+			pos = Position{Line: pos.Line + 1, Column: 1}
+		} else {
+			pos.Column++
+		}
+		decoded += size
+		i += size
+	}
+
+	return pos
+}
@@ -0,0 +1,191 @@
+package theme
+
+// valueType is the expected type of a Starship config option
+type valueType int
+
+const (
+	typeAny valueType = iota
+	typeString
+	typeBool
+	typeInt
+	typeNumber // Integer or float
+	typeStringArray
+	typeTable
+	typeStringOrBool
+	typeStringOrArray
+)
+
+func (t valueType) String() string {
+	switch t {
+	case typeString:
+		return "a string"
+	case typeBool:
+		return "a boolean"
+	case typeInt:
+		return "an integer"
+	case typeNumber:
+		return "a number"
+	case typeStringArray:
+		return "an array of strings"
+	case typeTable:
+		return "a table"
+	case typeStringOrBool:
+		return "a string or boolean"
+	case typeStringOrArray:
+		return "a string or array of strings"
+	default:
+		return "any value"
+	}
+}
+
+// topLevelOptions are the options allowed outside of module tables
+var topLevelOptions = map[string]valueType{
+	"$schema":             typeString,
+	"format":              typeString,
+	"right_format":        typeString,
+	"continuation_prompt": typeString,
+	"scan_timeout":        typeInt,
+	"command_timeout":     typeInt,
+	"add_newline":         typeBool,
+	"follow_symlinks":     typeBool,
+	"palette":             typeString,
+	"palettes":            typeTable,
+	"profiles":            typeTable,
+}
+
+// knownModules are all modules Starship supports, as of Starship 1.23
+var knownModules = map[string]bool{
+	"aws": true, "azure": true, "battery": true, "buf": true, "bun": true, "c": true,
+	"character": true, "cmake": true, "cmd_duration": true, "cobol": true, "conda": true,
+	"container": true, "cpp": true, "crystal": true, "custom": true, "daml": true, "dart": true,
+	"deno": true, "directory": true, "direnv": true, "docker_context": true, "dotnet": true,
+	"elixir": true, "elm": true, "env_var": true, "erlang": true, "fennel": true, "fill": true,
+	"fossil_branch": true, "fossil_metrics": true, "gcloud": true, "git_branch": true,
+	"git_commit": true, "git_metrics": true, "git_state": true, "git_status": true,
+	"gleam": true, "golang": true, "gradle": true, "guix_shell": true, "haskell": true,
+	"haxe": true, "helm": true, "hg_branch": true, "hg_state": true, "hostname": true,
+	"java": true, "jobs": true, "julia": true, "kotlin": true, "kubernetes": true,
+	"line_break": true, "localip": true, "lua": true, "memory_usage": true, "meson": true,
+	"mise": true, "mojo": true, "nats": true, "netns": true, "nim": true, "nix_shell": true,
+	"nodejs": true, "ocaml": true, "odin": true, "opa": true, "openstack": true, "os": true,
+	"package": true, "perl": true, "php": true, "pijul_channel": true, "pixi": true,
+	"pulumi": true, "purescript": true, "python": true, "quarto": true, "raku": true,
+	"red": true, "rlang": true, "ruby": true, "rust": true, "scala": true, "shell": true,
+	"shlvl": true, "singularity": true, "solidity": true, "spack": true, "status": true,
+	"sudo": true, "swift": true, "terraform": true, "time": true, "typst": true,
+	"username": true, "vagrant": true, "vcsh": true, "vlang": true, "xmake": true, "zig": true,
+}
+
+// moduleOptions maps option names used across modules to their type.
+// Options not listed here are not type checked.
+var moduleOptions = map[string]valueType{
+	// Shared by (almost) all modules
+	"format":            typeString,
+	"style":             typeString,
+	"symbol":            typeString,
+	"disabled":          typeBool,
+	"version_format":    typeString,
+	"detect_extensions": typeStringArray,
+	"detect_files":      typeStringArray,
+	"detect_folders":    typeStringArray,
+	"truncation_length": typeInt,
+	"truncation_symbol": typeString,
+
+	// character
+	"success_symbol":            typeString,
+	"error_symbol":              typeString,
+	"vimcmd_symbol":             typeString,
+	"vimcmd_visual_symbol":      typeString,
+	"vimcmd_replace_symbol":     typeString,
+	"vimcmd_replace_one_symbol": typeString,
+
+	// directory
+	"truncate_to_repo":          typeBool,
+	"fish_style_pwd_dir_length": typeInt,
+	"use_logical_path":          typeBool,
+	"read_only":                 typeString,
+	"read_only_style":           typeString,
+	"home_symbol":               typeString,
+	"use_os_path_sep":           typeBool,
+	"repo_root_style":           typeString,
+	"repo_root_format":          typeString,
+	"before_repo_root_style":    typeString,
+	"substitutions":             typeTable,
+
+	// cmd_duration, time, status, ...
+	"min_time":              typeInt,
+	"show_milliseconds":     typeBool,
+	"show_notifications":    typeBool,
+	"min_time_to_notify":    typeInt,
+	"time_format":           typeString,
+	"utc_time_offset":       typeString,
+	"time_range":            typeString,
+	"threshold":             typeNumber,
+	"number_threshold":      typeInt,
+	"symbol_threshold":      typeInt,
+	"recognize_signal_code": typeBool,
+	"map_symbol":            typeBool,
+	"pipestatus":            typeBool,
+	"pipestatus_separator":  typeString,
+	"pipestatus_format":     typeString,
+
+	// git
+	"only_attached":      typeBool,
+	"always_show_remote": typeBool,
+	"ignore_branches":    typeStringArray,
+	"commit_hash_length": typeInt,
+	"only_detached":      typeBool,
+	"tag_disabled":       typeBool,
+	"tag_symbol":         typeString,
+	"ignore_submodules":  typeBool,
+	"only_nonzero_diffs": typeBool,
+	"added_style":        typeString,
+	"deleted_style":      typeString,
+
+	// hostname, username, os, shell
+	"ssh_only":      typeBool,
+	"ssh_symbol":    typeString,
+	"trim_at":       typeString,
+	"show_always":   typeBool,
+	"style_root":    typeString,
+	"style_user":    typeString,
+	"symbols":       typeTable,
+	"contexts":      typeAny,
+	"python_binary": typeAny, // A string, or an array of strings and arrays of arguments
+}
+
+// customOptions are the options of a [custom.<name>] module
+var customOptions = map[string]valueType{
+	"command":           typeString,
+	"when":              typeStringOrBool,
+	"shell":             typeStringOrArray,
+	"require_repo":      typeBool,
+	"os":                typeString,
+	"description":       typeString,
+	"symbol":            typeString,
+	"style":             typeString,
+	"format":            typeString,
+	"disabled":          typeBool,
+	"ignore_timeout":    typeBool,
+	"use_stdin":         typeBool,
+	"unsafe_no_escape":  typeBool,
+	"detect_extensions": typeStringArray,
+	"detect_files":      typeStringArray,
+	"detect_folders":    typeStringArray,
+}
+
+// deprecatedOptions maps options that Starship no longer supports to a hint on what to use instead.
+// Keys are "module.option", "*.option" for any module, or "option" for top-level options.
+var deprecatedOptions = map[string]string{
+	"prompt_order":                    `use "format" instead`,
+	"*.prefix":                        `use "format" instead`,
+	"*.suffix":                        `use "format" instead`,
+	"character.symbol":                `use "success_symbol" and "error_symbol" instead`,
+	"character.use_symbol_for_status": `use "success_symbol" and "error_symbol" instead`,
+	"character.style_success":         `use "success_symbol" with a style instead`,
+	"character.style_failure":         `use "error_symbol" with a style instead`,
+	"kubernetes.context_aliases":      `use "contexts" instead`,
+	"kubernetes.user_aliases":         `use "contexts" instead`,
+	"git_status.show_sync_count":      `use "ahead", "behind" and "diverged" with $count instead`,
+	"time.use_12hr":                   `use "time_format" instead`,
+}
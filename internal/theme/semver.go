@@ -0,0 +1,136 @@
+package theme
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Parts of SemVer 2.0 versions. Numbers and numeric prerelease identifiers have no leading zeros,
+// so "01.2" is not another spelling of "1.2". Build metadata may have them.
+const (
+	numberPattern               = `(?:0|[1-9][0-9]*)`
+	prereleaseIdentifierPattern = `(?:0|[1-9][0-9]*|[0-9]*[A-Za-z-][0-9A-Za-z-]*)`
+	prereleasePattern           = prereleaseIdentifierPattern + `(?:\.` + prereleaseIdentifierPattern + `)*`
+)
+
+// versionPattern matches SemVer 2.0 versions. The patch component is optional,
+// so the original "major.minor" theme versions (e.g. "1.2") stay valid.
+const versionPattern = numberPattern + `\.` + numberPattern + `(?:\.` + numberPattern + `)?` +
+	`(?:-` + prereleasePattern + `)?` +
+	`(?:\+[0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*)?`
+
+var versionRegex = regexp.MustCompile(`^v?` + versionPattern + `$`)
+
+// Version is a parsed semantic version
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease []string // Dot separated identifiers after "-", e.g. ["beta", "1"]
+	Build      string   // Build metadata after "+", ignored for precedence
+}
+
+// ParseVersion parses "1.2", "2.1.3", "3.0-beta.1" or "1.0.0+build.5", with an optional "v" prefix.
+// A missing patch component is treated as 0, so "1.2" equals "1.2.0".
+func ParseVersion(v string) (Version, bool) {
+	if !versionRegex.MatchString(v) {
+		return Version{}, false
+	}
+	v = strings.TrimPrefix(v, "v")
+
+	var version Version
+	if core, build, ok := strings.Cut(v, "+"); ok {
+		v = core
+		version.Build = build
+	}
+	if core, pre, ok := strings.Cut(v, "-"); ok {
+		v = core
+		version.Prerelease = strings.Split(pre, ".")
+	}
+
+	parts := strings.Split(v, ".")
+	numbers := make([]int, 3)
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return Version{}, false
+		}
+		numbers[i] = n
+	}
+
+	version.Major, version.Minor, version.Patch = numbers[0], numbers[1], numbers[2]
+	return version, true
+}
+
+// Compare returns >0 if v > o, <0 if v < o and 0 if both have the same precedence.
+// Follows SemVer 2.0: a prerelease has lower precedence than the release, build metadata is ignored.
+func (v Version) Compare(o Version) int {
+	if v.Major != o.Major {
+		return v.Major - o.Major
+	}
+	if v.Minor != o.Minor {
+		return v.Minor - o.Minor
+	}
+	if v.Patch != o.Patch {
+		return v.Patch - o.Patch
+	}
+
+	// A version without prerelease is greater than one with
+	switch {
+	case len(v.Prerelease) == 0 && len(o.Prerelease) == 0:
+		return 0
+	case len(v.Prerelease) == 0:
+		return 1
+	case len(o.Prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(v.Prerelease) && i < len(o.Prerelease); i++ {
+		if c := comparePrereleaseIdentifier(v.Prerelease[i], o.Prerelease[i]); c != 0 {
+			return c
+		}
+	}
+
+	// All shared identifiers are equal, the longer set wins
+	return len(v.Prerelease) - len(o.Prerelease)
+}
+
+// IsPrerelease reports whether the version has prerelease identifiers
+func (v Version) IsPrerelease() bool {
+	return len(v.Prerelease) > 0
+}
+
+// comparePrereleaseIdentifier compares two prerelease identifiers.
+// Numeric identifiers compare numerically and have lower precedence than alphanumeric ones.
+func comparePrereleaseIdentifier(a, b string) int {
+	aNumeric, bNumeric := isNumericIdentifier(a), isNumericIdentifier(b)
+
+	switch {
+	case aNumeric && bNumeric:
+		// Without leading zeros the longer number is the larger one, this also works beyond int
+		if len(a) != len(b) {
+			return len(a) - len(b)
+		}
+		return strings.Compare(a, b)
+	case aNumeric:
+		return -1
+	case bNumeric:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+// isNumericIdentifier reports whether a prerelease identifier consists of ASCII digits only
+func isNumericIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,108 @@
+package theme
+
+import "testing"
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Version
+		ok   bool
+	}{
+		{"1.2", Version{Major: 1, Minor: 2}, true},
+		{"v1.2.3", Version{Major: 1, Minor: 2, Patch: 3}, true},
+		{"0.0.0", Version{}, true},
+		{"3.0-beta.1", Version{Major: 3, Prerelease: []string{"beta", "1"}}, true},
+		{"1.0.0-0a.1", Version{Major: 1, Prerelease: []string{"0a", "1"}}, true},
+		{"1.0.0+build.05", Version{Major: 1, Build: "build.05"}, true},
+		{"1.0.0-rc.1+build", Version{Major: 1, Prerelease: []string{"rc", "1"}, Build: "build"}, true},
+
+		// Leading zeros are not allowed in numbers and numeric prerelease identifiers
+		{"01.2", Version{}, false},
+		{"1.02", Version{}, false},
+		{"1.2.03", Version{}, false},
+		{"1.0.0-01", Version{}, false},
+
+		{"1", Version{}, false},
+		{"1.2.3.4", Version{}, false},
+		{"1.2-", Version{}, false},
+		{"1.2-beta..1", Version{}, false},
+		{"latest", Version{}, false},
+		{"", Version{}, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseVersion(tt.in)
+		if ok != tt.ok {
+			t.Errorf("ParseVersion(%q) ok = %v, want %v", tt.in, ok, tt.ok)
+			continue
+		}
+		if ok && !equalVersions(got, tt.want) {
+			t.Errorf("ParseVersion(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func equalVersions(a, b Version) bool {
+	if a.Major != b.Major || a.Minor != b.Minor || a.Patch != b.Patch || a.Build != b.Build || len(a.Prerelease) != len(b.Prerelease) {
+		return false
+	}
+	for i := range a.Prerelease {
+		if a.Prerelease[i] != b.Prerelease[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int // Sign of the result
+	}{
+		{"1.2", "1.2.0", 0},
+		{"v1.2.0", "1.2", 0},
+		{"1.2.0+build.1", "1.2.0+build.2", 0},
+		{"1.10", "1.9", 1},
+		{"2.0", "1.99.99", 1},
+		{"1.2.1", "1.2", 1},
+
+		// Precedence from the SemVer 2.0 spec:
+		// 1.0.0-alpha < 1.0.0-alpha.1 < 1.0.0-alpha.beta < 1.0.0-beta < 1.0.0-beta.2 < 1.0.0-beta.11 < 1.0.0-rc.1 < 1.0.0
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"1.0.0-alpha.1", "1.0.0-alpha.beta", -1},
+		{"1.0.0-alpha.beta", "1.0.0-beta", -1},
+		{"1.0.0-beta", "1.0.0-beta.2", -1},
+		{"1.0.0-beta.2", "1.0.0-beta.11", -1},
+		{"1.0.0-beta.11", "1.0.0-rc.1", -1},
+		{"1.0.0-rc.1", "1.0.0", -1},
+		{"1.0-rc.1", "1.0.0", -1},
+
+		// Identifiers are only numeric if they are all digits
+		{"1.0.0-1", "1.0.0--1", -1},
+		{"1.0.0-99999999999999999999", "1.0.0-100", 1},
+
+		// Non-semver versions sort last
+		{"latest", "1.0", -1},
+		{"1.0", "latest", 1},
+	}
+
+	for _, tt := range tests {
+		got := CompareVersions(tt.a, tt.b)
+		if sign(got) != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want sign %d", tt.a, tt.b, got, tt.want)
+		}
+		if back := CompareVersions(tt.b, tt.a); sign(back) != -tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want sign %d", tt.b, tt.a, back, -tt.want)
+		}
+	}
+}
+
+func sign(n int) int {
+	switch {
+	case n > 0:
+		return 1
+	case n < 0:
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,92 @@
+package theme
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// customVariableRegex matches $custom and ${custom.<name>} references in format strings
+var customVariableRegex = regexp.MustCompile(`\$custom\b|\$\{custom\.[A-Za-z0-9_]+\}`)
+
+// StripCustom returns content without its [custom.*] modules and the $custom references to them,
+// so the theme looks the same except for the output of custom commands.
+// Everything else, including comments and formatting, is kept as is.
+func StripCustom(content string) (string, error) {
+	var out []string
+	inCustomTable := false
+	table := ""
+	inMultiline := ""
+	depth := 0         // Nesting of a multi-line array or inline table value
+	dropValue := false // Whether the current multi-line value belongs to a dropped key
+
+	// The final newline is added back at the end, it would get lost if the last table is dropped
+	body, trailingNewline := strings.CutSuffix(content, "\n")
+
+	for _, line := range strings.Split(body, "\n") {
+		drop := inCustomTable
+
+		if inMultiline != "" {
+			// Continuation lines of multi-line strings belong to the key they started at
+			if strings.Count(line, inMultiline)%2 == 1 {
+				inMultiline = ""
+			}
+			drop = drop || dropValue
+		} else if depth > 0 {
+			// So do the elements of multi-line arrays, even if they start with "[" like a table header
+			depth = bracketDepth(line, depth)
+			drop = drop || dropValue
+		} else if trimmed := strings.TrimLeft(line, " \t"); strings.HasPrefix(trimmed, "[") {
+			header := strings.TrimLeft(trimmed, "[")
+			if end := strings.Index(header, "]"); end >= 0 {
+				table = normalizeKey(header[:end])
+				inCustomTable = isCustomKey(table)
+				drop = inCustomTable
+			}
+		} else if eq := keyValueSeparator(trimmed); eq >= 0 && !strings.HasPrefix(trimmed, "#") {
+			// Dotted keys like custom.foo.command = "..." can appear outside of a custom table
+			key := normalizeKey(trimmed[:eq])
+			if table != "" {
+				key = table + "." + key
+			}
+			drop = drop || isCustomKey(key)
+
+			if depth = bracketDepth(trimmed[eq+1:], 0); depth > 0 {
+				dropValue = drop
+			}
+			for _, delim := range []string{`"""`, `'''`} {
+				if strings.Count(trimmed[eq+1:], delim)%2 == 1 {
+					inMultiline = delim
+					dropValue = drop
+					break
+				}
+			}
+		}
+
+		if !drop {
+			out = append(out, customVariableRegex.ReplaceAllString(line, ""))
+		}
+	}
+
+	stripped := strings.Join(out, "\n")
+	if trailingNewline {
+		stripped += "\n"
+	}
+
+	// Make sure the result is still a valid config without custom modules
+	var config map[string]interface{}
+	if _, err := toml.Decode(stripped, &config); err != nil {
+		return "", fmt.Errorf("failed to strip custom modules: %w", err)
+	}
+	if _, ok := config["custom"]; ok {
+		return "", fmt.Errorf("failed to strip custom modules: unsupported [custom] syntax")
+	}
+
+	return stripped, nil
+}
+
+func isCustomKey(key string) bool {
+	return key == "custom" || strings.HasPrefix(key, "custom.")
+}
@@ -0,0 +1,125 @@
+package theme
+
+import "testing"
+
+func TestStripCustom(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "custom table",
+			content: "format = \"$all$custom\"\n\n[custom.foo]\ncommand = \"echo hi\"\nwhen = true\n",
+			want:    "format = \"$all\"\n\n",
+		},
+		{
+			name:    "custom table between others",
+			content: "[git_branch]\nsymbol = \"x\"\n\n[custom.foo]\ncommand = \"echo hi\"\n\n[directory]\nstyle = \"bold\"\n",
+			want:    "[git_branch]\nsymbol = \"x\"\n\n[directory]\nstyle = \"bold\"\n",
+		},
+		{
+			name:    "dotted custom key",
+			content: "custom.foo.command = \"echo hi\"\nadd_newline = false\n",
+			want:    "add_newline = false\n",
+		},
+		{
+			name:    "custom variable references",
+			content: "format = \"${custom.foo}$directory\"\n",
+			want:    "format = \"$directory\"\n",
+		},
+		{
+			name:    "multi-line string in custom table",
+			content: "[custom.foo]\ncommand = '''\necho hi\n[not.a.table]\n'''\n\n[directory]\nstyle = \"bold\"\n",
+			want:    "[directory]\nstyle = \"bold\"\n",
+		},
+		{
+			name:    "multi-line array with array elements is kept",
+			content: "[palettes]\nnested = [\n  [\"a\", \"b\"],\n  [\"c\"],\n]\nx = \"y\"\n",
+			want:    "[palettes]\nnested = [\n  [\"a\", \"b\"],\n  [\"c\"],\n]\nx = \"y\"\n",
+		},
+		{
+			name:    "multi-line array in custom table is dropped",
+			content: "[custom.foo]\ncommand = \"echo\"\nfiles = [\n  [\"a\"],\n]\n[directory]\nstyle = \"bold\"\n",
+			want:    "[directory]\nstyle = \"bold\"\n",
+		},
+		{
+			name:    "brackets in strings and comments",
+			content: "[c]\ncommands = [ # [[\n  \"]\",\n  ['[python]'],\n]\n",
+			want:    "[c]\ncommands = [ # [[\n  \"]\",\n  ['[python]'],\n]\n",
+		},
+		{
+			name:    "inline custom table",
+			content: "custom = { foo = { command = \"echo\" } }\nadd_newline = false\n",
+			want:    "add_newline = false\n",
+		},
+		{
+			name:    "no trailing newline",
+			content: "add_newline = false\n[custom.foo]\ncommand = \"echo\"",
+			want:    "add_newline = false",
+		},
+		{
+			name:    "trailing newline kept when last table is dropped",
+			content: "add_newline = false\n[custom.foo]\ncommand = \"echo\"\n",
+			want:    "add_newline = false\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := StripCustom(tt.content)
+			if err != nil {
+				t.Fatalf("StripCustom() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("StripCustom() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMapSourceMultiLineArrays(t *testing.T) {
+	content := "[palettes]\nnested = [\n  [\"a\", \"b\"],\n]\nafter = \"x\"\n\n[directory]\nstyle = \"bold\"\n"
+	m := mapSource(content)
+
+	tests := []struct {
+		path string
+		want Position
+	}{
+		{"palettes", Position{Line: 1, Column: 1}},
+		{"palettes.nested", Position{Line: 2, Column: 1}},
+		{"palettes.after", Position{Line: 5, Column: 1}},
+		{"directory.style", Position{Line: 8, Column: 1}},
+	}
+	for _, tt := range tests {
+		if got := m.lookup(tt.path); got != tt.want {
+			t.Errorf("lookup(%q) = %+v, want %+v", tt.path, got, tt.want)
+		}
+	}
+
+	if _, ok := m.keys[`"a", "b"`]; ok {
+		t.Errorf("array element was mapped as a table header")
+	}
+}
+
+func TestBracketDepth(t *testing.T) {
+	tests := []struct {
+		in    string
+		depth int
+		want  int
+	}{
+		{"[", 0, 1},
+		{"[1, 2]", 0, 0},
+		{"[[1], [", 0, 2},
+		{`"[" # [`, 0, 0},
+		{`'[', "\"[", ]`, 1, 0},
+		{`"""[""" [`, 0, 1},
+		{"{ a = [", 0, 2},
+		{"]]]", 1, 0},
+	}
+	for _, tt := range tests {
+		if got := bracketDepth(tt.in, tt.depth); got != tt.want {
+			t.Errorf("bracketDepth(%q, %d) = %d, want %d", tt.in, tt.depth, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package theme
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Style is a parsed Starship style string like "bold fg:#89b4fa bg:surface0"
+type Style struct {
+	Attributes []string // e.g. "bold", "italic"
+	Foreground StyleColor
+	Background StyleColor
+}
+
+// StyleColor is a color in a style string. The zero value means the color is not set.
+type StyleColor struct {
+	Name   string // Lowercase, e.g. "red", "bright-blue", "#89b4fa", "208" or a palette color
+	Offset int    // Byte offset of the color in the style string
+}
+
+// IsPalette reports whether the color is not built in, so it must be defined in the active palette
+func (c StyleColor) IsPalette() bool {
+	return c.Name != "" && !isBuiltinColor(c.Name)
+}
+
+var styleAttributes = map[string]bool{
+	"bold": true, "italic": true, "underline": true, "dimmed": true,
+	"inverted": true, "blink": true, "hidden": true, "strikethrough": true,
+}
+
+var namedColors = map[string]bool{
+	"black": true, "red": true, "green": true, "yellow": true,
+	"blue": true, "purple": true, "cyan": true, "white": true,
+}
+
+// ParseStyle parses a Starship style string.
+// Words are attributes, "none", "fg:<color>", "bg:<color>" or a bare color for the foreground.
+// Colors are names like "red" or "bright-red", "#rrggbb" hex codes, 0-255 ANSI codes or palette colors.
+// Variables like $style (allowed in the style of a format string group) are skipped.
+func ParseStyle(s string) (*Style, error) {
+	style := &Style{}
+
+	for _, word := range splitWords(s) {
+		token := strings.ToLower(word.text)
+
+		switch {
+		case strings.HasPrefix(token, "$"):
+			continue
+		case token == "none":
+			*style = Style{}
+		case styleAttributes[token]:
+			style.Attributes = append(style.Attributes, token)
+		case strings.HasPrefix(token, "fg:") || strings.HasPrefix(token, "bg:"):
+			color := StyleColor{Name: token[3:], Offset: word.offset + 3}
+			if err := checkColor(color); err != nil {
+				return nil, err
+			}
+			if token[0] == 'f' {
+				style.Foreground = color
+			} else {
+				style.Background = color
+			}
+		default:
+			color := StyleColor{Name: token, Offset: word.offset}
+			if err := checkColor(color); err != nil {
+				return nil, err
+			}
+			style.Foreground = color
+		}
+	}
+
+	return style, nil
+}
+
+// checkColor rejects colors that can never be valid, regardless of the palette
+func checkColor(c StyleColor) error {
+	name := c.Name
+	switch {
+	case name == "":
+		return &SyntaxError{Offset: c.Offset, Message: "missing color"}
+	case strings.HasPrefix(name, "#"):
+		if len(name) != 7 || strings.IndexFunc(name[1:], isNotHexDigit) >= 0 {
+			return &SyntaxError{Offset: c.Offset, Message: fmt.Sprintf("invalid hex color %q, expected #rrggbb", name)}
+		}
+	case name[0] >= '0' && name[0] <= '9':
+		if n, err := strconv.Atoi(name); err != nil || n > 255 {
+			return &SyntaxError{Offset: c.Offset, Message: fmt.Sprintf("invalid color %q, ANSI color codes must be 0-255", name)}
+		}
+	case strings.ContainsAny(name, ":()[]"):
+		return &SyntaxError{Offset: c.Offset, Message: fmt.Sprintf("invalid color %q", name)}
+	}
+	return nil
+}
+
+// isBuiltinColor reports whether name is a color Starship knows without a palette
+func isBuiltinColor(name string) bool {
+	switch {
+	case name == "none" || name == "prev_fg" || name == "prev_bg":
+		return true
+	case strings.HasPrefix(name, "#"):
+		return true
+	case name[0] >= '0' && name[0] <= '9':
+		return true
+	default:
+		return namedColors[strings.TrimPrefix(name, "bright-")]
+	}
+}
+
+func isNotHexDigit(r rune) bool {
+	return !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f' || r >= 'A' && r <= 'F')
+}
+
+type word struct {
+	text   string
+	offset int
+}
+
+// splitWords splits s at whitespace and remembers where each word starts
+func splitWords(s string) []word {
+	var words []word
+	start := -1
+	for i := 0; i <= len(s); i++ {
+		if i == len(s) || s[i] == ' ' || s[i] == '\t' || s[i] == '\n' {
+			if start >= 0 {
+				words = append(words, word{text: s[start:i], offset: start})
+				start = -1
+			}
+		} else if start < 0 {
+			start = i
+		}
+	}
+	return words
+}
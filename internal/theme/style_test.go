@@ -0,0 +1,86 @@
+package theme
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestParseStyle(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Style
+	}{
+		{"", Style{}},
+		{"bold", Style{Attributes: []string{"bold"}}},
+		{"Bold Italic red", Style{Attributes: []string{"bold", "italic"}, Foreground: StyleColor{"red", 12}}},
+		{"fg:#89B4FA bg:surface0", Style{Foreground: StyleColor{"#89b4fa", 3}, Background: StyleColor{"surface0", 14}}},
+		{"bg:208 bright-blue", Style{Foreground: StyleColor{"bright-blue", 7}, Background: StyleColor{"208", 3}}},
+		{"bold none", Style{}},
+		{"none fg:prev_bg", Style{Foreground: StyleColor{"prev_bg", 8}}},
+		{"$style bold", Style{Attributes: []string{"bold"}}},
+		{"  green\tunderline", Style{Attributes: []string{"underline"}, Foreground: StyleColor{"green", 2}}},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseStyle(tt.in)
+		if err != nil {
+			t.Errorf("ParseStyle(%q) error = %v", tt.in, err)
+			continue
+		}
+		if !slices.Equal(got.Attributes, tt.want.Attributes) || got.Foreground != tt.want.Foreground || got.Background != tt.want.Background {
+			t.Errorf("ParseStyle(%q) = %+v, want %+v", tt.in, *got, tt.want)
+		}
+	}
+}
+
+func TestParseStyleErrors(t *testing.T) {
+	tests := []struct {
+		in     string
+		offset int
+	}{
+		{"fg:", 3},
+		{"bold bg:", 8},
+		{"#89b4f", 0},
+		{"fg:#89b4fg", 3},
+		{"256", 0},
+		{"bold 12a", 5},
+		{"fg:red:blue", 3},
+		{"red(", 0},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseStyle(tt.in)
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("ParseStyle(%q) error = %v, want a *SyntaxError", tt.in, err)
+			continue
+		}
+		if syntaxErr.Offset != tt.offset {
+			t.Errorf("ParseStyle(%q) error at offset %d, want %d (%v)", tt.in, syntaxErr.Offset, tt.offset, err)
+		}
+	}
+}
+
+func TestStyleColorIsPalette(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"", false},
+		{"red", false},
+		{"bright-purple", false},
+		{"#89b4fa", false},
+		{"208", false},
+		{"prev_fg", false},
+		{"none", false},
+		{"surface0", true},
+		{"bright-surface", true},
+	}
+
+	for _, tt := range tests {
+		if got := (StyleColor{Name: tt.name}).IsPalette(); got != tt.want {
+			t.Errorf("StyleColor{%q}.IsPalette() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
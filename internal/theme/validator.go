@@ -1,17 +1,64 @@
 package theme
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
 )
 
+// Severity of a validation diagnostic
+type Severity int
+
+const (
+	SeverityWarning Severity = iota
+	SeverityError
+)
+
+func (s Severity) String() string {
+	if s == SeverityError {
+		return "error"
+	}
+	return "warning"
+}
+
+// Diagnostic is a single problem found in a config
+type Diagnostic struct {
+	Severity Severity
+	Position Position // Zero if the position is unknown
+	Key      string   // Dotted key path, e.g. "git_branch.format"
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	if d.Position.Line == 0 {
+		return fmt.Sprintf("%s: %s", d.Severity, d.Message)
+	}
+	return fmt.Sprintf("line %d, column %d: %s: %s", d.Position.Line, d.Position.Column, d.Severity, d.Message)
+}
+
 // ValidationResult contains the validation outcome
 type ValidationResult struct {
 	Valid             bool
 	HasCustomCommands bool
-	Error             error
+	Commands          []Command    // Everything the config makes Starship execute, for review
+	Error             error        // All errors joined, nil if Valid
+	Diagnostics       []Diagnostic // Errors and warnings, sorted by position
+}
+
+// Warnings returns the diagnostics that do not make the config invalid
+func (r ValidationResult) Warnings() []Diagnostic {
+	var warnings []Diagnostic
+	for _, d := range r.Diagnostics {
+		if d.Severity == SeverityWarning {
+			warnings = append(warnings, d)
+		}
+	}
+	return warnings
 }
 
 // ValidateConfig checks if the TOML is valid and identifies security concerns
@@ -24,29 +71,463 @@ func ValidateConfig(path string) (ValidationResult, error) {
 	return ValidateConfigContent(string(data))
 }
 
-// ValidateConfigContent validates TOML content and returns validation result
+// MaxConfigSize is the largest config accepted, in bytes
+const MaxConfigSize = 100 * 1024
+
+// ValidateConfigContent validates TOML content against the Starship config schema
 // Custom commands are detected but NOT blocked - caller decides how to handle
 func ValidateConfigContent(content string) (ValidationResult, error) {
-	result := ValidationResult{Valid: true}
+	// 1. Size check (prevent abuse)
+	if len(content) > MaxConfigSize {
+		return ValidationResult{Valid: false, Error: fmt.Errorf("config too large (max 100KB)")}, nil
+	}
 
-	// 1. Check TOML syntax
+	// 2. Check TOML syntax
 	var config map[string]interface{}
 	if _, err := toml.Decode(content, &config); err != nil {
-		return ValidationResult{Valid: false, Error: fmt.Errorf("invalid TOML: %w", err)}, nil
+		d := Diagnostic{Severity: SeverityError, Message: fmt.Sprintf("invalid TOML: %v", err)}
+		var parseErr toml.ParseError
+		if errors.As(err, &parseErr) {
+			d.Position = Position{Line: parseErr.Position.Line, Column: parseErr.Position.Col}
+			d.Message = "invalid TOML: " + parseErr.Message
+		}
+		return ValidationResult{Valid: false, Error: fmt.Errorf("invalid TOML: %w", err), Diagnostics: []Diagnostic{d}}, nil
 	}
 
-	// 2. Check for custom commands (security warning, not blocking)
+	result := ValidationResult{Valid: true}
+
+	// 3. Check for custom commands (security warning, not blocking)
 	if custom, ok := config["custom"]; ok {
 		customMap, ok := custom.(map[string]interface{})
 		if ok && len(customMap) > 0 {
 			result.HasCustomCommands = true
 		}
 	}
+	source := mapSource(content)
+	result.Commands = extractCommands(config, source)
+	if len(result.Commands) > 0 {
+		result.HasCustomCommands = true
+	}
 
-	// 3. Size check (prevent abuse)
-	if len(content) > 100*1024 { // 100KB max
-		return ValidationResult{Valid: false, Error: fmt.Errorf("config too large (max 100KB)")}, nil
+	// 4. Check against the Starship schema
+	v := &schemaValidator{source: source}
+	v.validate(config)
+	result.Diagnostics = v.diagnostics
+
+	sort.SliceStable(result.Diagnostics, func(i, j int) bool {
+		a, b := result.Diagnostics[i].Position, result.Diagnostics[j].Position
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+
+	var errs []error
+	for _, d := range result.Diagnostics {
+		if d.Severity == SeverityError {
+			errs = append(errs, errors.New(d.String()))
+		}
+	}
+	if len(errs) > 0 {
+		result.Valid = false
+		result.Error = errors.Join(errs...)
 	}
 
 	return result, nil
 }
+
+// schemaValidator collects diagnostics while walking a decoded config
+type schemaValidator struct {
+	source        *sourceMap
+	customModules map[string]bool // Names of the [custom.<name>] modules
+	palette       map[string]bool // Colors of the active palette
+	diagnostics   []Diagnostic
+}
+
+func (v *schemaValidator) report(severity Severity, key, format string, args ...interface{}) {
+	v.reportAt(severity, key, v.source.lookup(key), format, args...)
+}
+
+func (v *schemaValidator) reportAt(severity Severity, key string, pos Position, format string, args ...interface{}) {
+	v.diagnostics = append(v.diagnostics, Diagnostic{
+		Severity: severity,
+		Position: pos,
+		Key:      key,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *schemaValidator) validate(config map[string]interface{}) {
+	v.collectContext(config)
+
+	for key, value := range config {
+		if hint, ok := deprecatedOptions[key]; ok {
+			v.report(SeverityWarning, key, "%q is deprecated, %s", key, hint)
+			continue
+		}
+
+		if expected, ok := topLevelOptions[key]; ok {
+			if v.checkType(key, value, expected) {
+				v.lintString(key, key, value)
+			}
+			if key == "palettes" {
+				v.validatePalettes(value)
+			}
+			continue
+		}
+
+		module, isTable := value.(map[string]interface{})
+		if !isTable {
+			if suggestion := closestMatch(key, topLevelOptions); suggestion != "" {
+				v.report(SeverityError, key, "unknown option %q, did you mean %q?", key, suggestion)
+			} else {
+				v.report(SeverityWarning, key, "unknown option %q", key)
+			}
+			continue
+		}
+
+		if !knownModules[key] {
+			// A name close to a known module is almost certainly a typo that Starship would ignore
+			if suggestion := closestMatch(key, knownModules); suggestion != "" {
+				v.report(SeverityError, key, "unknown module [%s], did you mean [%s]?", key, suggestion)
+			} else {
+				v.report(SeverityWarning, key, "unknown module [%s]", key)
+			}
+			continue
+		}
+
+		switch key {
+		case "custom":
+			for name, value := range module {
+				path := "custom." + name
+				custom, ok := value.(map[string]interface{})
+				if !ok {
+					v.report(SeverityError, path, "[%s] must be a table", path)
+					continue
+				}
+				v.validateOptions(path, custom, customOptions)
+
+				// Custom modules have a fixed set of options, so anything else is likely a typo
+				for name := range custom {
+					if _, ok := customOptions[name]; ok {
+						continue
+					}
+					if suggestion := closestMatch(name, customOptions); suggestion != "" {
+						v.report(SeverityWarning, path+"."+name, "[%s] unknown option %q, did you mean %q?", path, name, suggestion)
+					} else {
+						v.report(SeverityWarning, path+"."+name, "[%s] unknown option %q", path, name)
+					}
+				}
+			}
+		case "env_var":
+			// env_var is either a single module or a table of modules, one per variable
+			for name, value := range module {
+				if sub, ok := value.(map[string]interface{}); ok {
+					v.validateModule("env_var."+name, "env_var", sub)
+					delete(module, name)
+				}
+			}
+			v.validateModule(key, key, module)
+		default:
+			v.validateModule(key, key, module)
+		}
+	}
+}
+
+// validateModule checks the options of a built-in module
+func (v *schemaValidator) validateModule(path, module string, options map[string]interface{}) {
+	for name := range options {
+		if hint, ok := deprecatedOptions[module+"."+name]; ok {
+			v.report(SeverityWarning, path+"."+name, "[%s] %q is deprecated, %s", path, name, hint)
+			delete(options, name)
+		} else if hint, ok := deprecatedOptions["*."+name]; ok {
+			v.report(SeverityWarning, path+"."+name, "[%s] %q is deprecated, %s", path, name, hint)
+			delete(options, name)
+		}
+	}
+
+	v.validateOptions(path, options, moduleOptions)
+}
+
+// validateOptions type checks every option that has a known type
+func (v *schemaValidator) validateOptions(path string, options map[string]interface{}, schema map[string]valueType) {
+	for name, value := range options {
+		if expected, ok := schema[name]; ok && v.checkType(path+"."+name, value, expected) {
+			v.lintString(path+"."+name, name, value)
+		}
+	}
+}
+
+// checkType reports an error if value does not have the expected type
+func (v *schemaValidator) checkType(key string, value interface{}, expected valueType) bool {
+	if !matchesType(value, expected) {
+		v.report(SeverityError, key, "%q must be %s, got %s", key, expected, describeValue(value))
+		return false
+	}
+	return true
+}
+
+// collectContext gathers what other checks need to know about the whole config
+func (v *schemaValidator) collectContext(config map[string]interface{}) {
+	v.customModules = make(map[string]bool)
+	if custom, ok := config["custom"].(map[string]interface{}); ok {
+		for name := range custom {
+			v.customModules[name] = true
+		}
+	}
+
+	v.palette = make(map[string]bool)
+	name, ok := config["palette"].(string)
+	if !ok {
+		return
+	}
+	palettes, _ := config["palettes"].(map[string]interface{})
+	colors, ok := palettes[name].(map[string]interface{})
+	if !ok {
+		v.report(SeverityWarning, "palette", "palette %q is not defined in [palettes]", name)
+		return
+	}
+	for color := range colors {
+		v.palette[strings.ToLower(color)] = true
+	}
+}
+
+// validatePalettes checks that every palette color is a built-in color
+func (v *schemaValidator) validatePalettes(value interface{}) {
+	palettes, _ := value.(map[string]interface{})
+	for name, value := range palettes {
+		colors, ok := value.(map[string]interface{})
+		if !ok {
+			v.report(SeverityError, "palettes."+name, "[palettes.%s] must be a table", name)
+			continue
+		}
+		for color, value := range colors {
+			path := "palettes." + name + "." + color
+			s, ok := value.(string)
+			if !ok {
+				v.report(SeverityError, path, "%q must be a string, got %s", path, describeValue(value))
+				continue
+			}
+			c := StyleColor{Name: strings.ToLower(s)}
+			if err := checkColor(c); err != nil {
+				v.reportSyntaxError(path, 0, err, "invalid palette color")
+			} else if c.IsPalette() {
+				v.report(SeverityError, path, "invalid palette color %q, palettes can only use built-in colors", s)
+			}
+		}
+	}
+}
+
+// lintString parses format and style strings
+func (v *schemaValidator) lintString(path, name string, value interface{}) {
+	s, ok := value.(string)
+	if !ok {
+		return
+	}
+
+	module, _, _ := strings.Cut(path, ".")
+	switch {
+	case isFormatOption(module, name):
+		v.lintFormat(path, s)
+	case isStyleOption(name):
+		v.lintStyle(path, s, 0)
+	}
+}
+
+// isFormatOption reports whether an option holds a Starship format string
+func isFormatOption(module, name string) bool {
+	switch {
+	case name == "format" || name == "right_format" || name == "continuation_prompt":
+		return true
+	case name == "time_format":
+		// strftime format, not a Starship format string
+		return false
+	case strings.HasSuffix(name, "_format"):
+		return true
+	default:
+		return module == "character" && strings.HasSuffix(name, "_symbol")
+	}
+}
+
+// isStyleOption reports whether an option holds a Starship style string
+func isStyleOption(name string) bool {
+	return name == "style" || strings.HasSuffix(name, "_style") || strings.HasPrefix(name, "style_")
+}
+
+func (v *schemaValidator) lintFormat(path, s string) {
+	format, err := ParseFormat(s)
+	if err != nil {
+		v.reportSyntaxError(path, 0, err, "invalid format string")
+		return
+	}
+
+	// The prompt formats are made of modules, so every variable must be a module
+	if path == "format" || path == "right_format" {
+		for _, variable := range format.Variables {
+			v.lintModuleVariable(path, variable)
+		}
+	}
+
+	for _, style := range format.Styles {
+		v.lintStyle(path, style.Style, style.Offset)
+	}
+}
+
+func (v *schemaValidator) lintModuleVariable(path string, variable FormatVariable) {
+	pos := v.source.valuePosition(path, variable.Offset)
+	module, name, scoped := strings.Cut(variable.Name, ".")
+
+	switch {
+	case scoped && module == "custom":
+		if !v.customModules[name] {
+			v.reportAt(SeverityWarning, path, pos, "${%s} is used in %q, but [custom.%s] is not defined", variable.Name, path, name)
+		}
+	case scoped && module == "env_var":
+		// Any environment variable can be shown
+	case !scoped && (variable.Name == "all" || knownModules[variable.Name]):
+		// A known module
+	default:
+		if suggestion := closestMatch(variable.Name, knownModules); suggestion != "" {
+			v.reportAt(SeverityError, path, pos, "unknown module $%s in %q, did you mean $%s?", variable.Name, path, suggestion)
+		} else {
+			v.reportAt(SeverityWarning, path, pos, "unknown module $%s in %q", variable.Name, path)
+		}
+	}
+}
+
+// lintStyle parses a style string that starts at offset in the value of path
+func (v *schemaValidator) lintStyle(path, s string, offset int) {
+	style, err := ParseStyle(s)
+	if err != nil {
+		v.reportSyntaxError(path, offset, err, fmt.Sprintf("invalid style %q", s))
+		return
+	}
+
+	for _, color := range []StyleColor{style.Foreground, style.Background} {
+		if color.IsPalette() && !v.palette[color.Name] {
+			pos := v.source.valuePosition(path, offset+color.Offset)
+			v.reportAt(SeverityWarning, path, pos, "unknown color %q in %q, it is neither a built-in color nor in the palette", color.Name, path)
+		}
+	}
+}
+
+// reportSyntaxError reports err from ParseFormat or ParseStyle at the exact position in the value of path
+func (v *schemaValidator) reportSyntaxError(path string, offset int, err error, context string) {
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		v.report(SeverityError, path, "%s in %q: %v", context, path, err)
+		return
+	}
+
+	pos := v.source.valuePosition(path, offset+syntaxErr.Offset)
+	v.reportAt(SeverityError, path, pos, "%s in %q: %s", context, path, syntaxErr.Message)
+}
+
+func matchesType(value interface{}, expected valueType) bool {
+	switch expected {
+	case typeString:
+		_, ok := value.(string)
+		return ok
+	case typeBool:
+		_, ok := value.(bool)
+		return ok
+	case typeInt:
+		_, ok := value.(int64)
+		return ok
+	case typeNumber:
+		switch value.(type) {
+		case int64, float64:
+			return true
+		}
+		return false
+	case typeStringArray:
+		return isStringArray(value)
+	case typeTable:
+		_, ok := value.(map[string]interface{})
+		return ok
+	case typeStringOrBool:
+		return matchesType(value, typeString) || matchesType(value, typeBool)
+	case typeStringOrArray:
+		return matchesType(value, typeString) || isStringArray(value)
+	default:
+		return true
+	}
+}
+
+func isStringArray(value interface{}) bool {
+	items, ok := value.([]interface{})
+	if !ok {
+		return false
+	}
+	for _, item := range items {
+		if _, ok := item.(string); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// describeValue names the TOML type of a decoded value for error messages
+func describeValue(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	case int64:
+		return "an integer"
+	case float64:
+		return "a float"
+	case []interface{}:
+		return "an array"
+	case []map[string]interface{}:
+		return "an array of tables"
+	case map[string]interface{}:
+		return "a table"
+	case time.Time:
+		return "a date"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// closestMatch returns the candidate within a small edit distance of name, or "" if there is none
+func closestMatch[V any](name string, candidates map[string]V) string {
+	best := ""
+	bestDistance := 0
+	for candidate := range candidates {
+		d := editDistance(strings.ToLower(name), candidate)
+		if best == "" || d < bestDistance || (d == bestDistance && candidate < best) {
+			best, bestDistance = candidate, d
+		}
+	}
+
+	// Allow roughly one typo per four characters, at most two
+	if best == "" || bestDistance > min(2, max(1, len(name)/4)) {
+		return ""
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
@@ -0,0 +1,113 @@
+package trust
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// AuthorKey is the signing key trusted for an author
+type AuthorKey struct {
+	Author    string    `json:"author"`
+	PublicKey string    `json:"public_key"` // Base64 minisign public key
+	KeyID     string    `json:"key_id"`
+	TrustedAt time.Time `json:"trusted_at"`
+}
+
+// Keyring holds the signing keys of authors, trusted on first use
+type Keyring struct {
+	Keys []AuthorKey `json:"keys"`
+}
+
+// KeyringPath returns the location of the keyring
+func KeyringPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "stellar", "keyring.json"), nil
+}
+
+// LoadKeyring reads the keyring, an empty keyring is returned if it does not exist yet
+func LoadKeyring() (*Keyring, error) {
+	path, err := KeyringPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Keyring{}, nil
+		}
+		return nil, err
+	}
+
+	var keyring Keyring
+	if err := json.Unmarshal(data, &keyring); err != nil {
+		return nil, fmt.Errorf("invalid keyring %s: %w", path, err)
+	}
+
+	return &keyring, nil
+}
+
+// Save writes the keyring
+func (k *Keyring) Save() error {
+	path, err := KeyringPath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(k, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temp file first, so a crash never leaves a truncated keyring behind
+	return writeFile(path, data, 0600)
+}
+
+// Lookup returns the key trusted for author, nil if there is none
+func (k *Keyring) Lookup(author string) *AuthorKey {
+	for i, key := range k.Keys {
+		if key.Author == author {
+			return &k.Keys[i]
+		}
+	}
+	return nil
+}
+
+// Trust records publicKey as the key of author, replacing the key trusted before
+func (k *Keyring) Trust(author, publicKey, keyID string) {
+	key := AuthorKey{
+		Author:    author,
+		PublicKey: publicKey,
+		KeyID:     keyID,
+		TrustedAt: time.Now().UTC(),
+	}
+
+	for i, existing := range k.Keys {
+		if existing.Author == author {
+			k.Keys[i] = key
+			return
+		}
+	}
+	k.Keys = append(k.Keys, key)
+}
+
+// Forget removes the key of author, returns false if none was trusted
+func (k *Keyring) Forget(author string) bool {
+	for i, key := range k.Keys {
+		if key.Author == author {
+			k.Keys = append(k.Keys[:i], k.Keys[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,197 @@
+package trust
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/a3chron/stellar/internal/theme"
+)
+
+// Status is the outcome of checking a theme against the trust store
+type Status int
+
+const (
+	// Unknown means no version of the theme was ever approved
+	Unknown Status = iota
+	// Changed means the theme was approved before, but with different commands
+	Changed
+	// Trusted means the exact content, or the same set of commands, was approved
+	Trusted
+)
+
+// Entry is an approved version of a theme
+type Entry struct {
+	Theme      string    `json:"theme"`   // "alice/rainbow"
+	Version    string    `json:"version"` // "1.2"
+	SHA256     string    `json:"sha256"`  // Hash of the approved config content
+	Commands   []string  `json:"commands"`
+	ApprovedAt time.Time `json:"approved_at"`
+}
+
+// Store is the database of approved themes
+type Store struct {
+	Entries []Entry `json:"entries"`
+}
+
+// StorePath returns the location of the trust database
+func StorePath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "stellar", "trust.json"), nil
+}
+
+// Load reads the trust database, an empty store is returned if it does not exist yet
+func Load() (*Store, error) {
+	path, err := StorePath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Store{}, nil
+		}
+		return nil, err
+	}
+
+	var store Store
+	if err := json.Unmarshal(data, &store); err != nil {
+		return nil, fmt.Errorf("invalid trust database %s: %w", path, err)
+	}
+
+	return &store, nil
+}
+
+// Save writes the trust database
+func (s *Store) Save() error {
+	path, err := StorePath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temp file first, so a crash never leaves a truncated database behind
+	return writeFile(path, data, 0600)
+}
+
+// writeFile writes data to path through a temp file of its own in the same directory, which is then renamed into place.
+// Neither a crash nor another stellar process saving at the same time can leave a partial file behind.
+func writeFile(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// Hash returns the SHA-256 of a config's content, as stored in entries
+func Hash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// DescribeCommands turns commands into the stable, sorted list of strings stored in entries
+func DescribeCommands(commands []theme.Command) []string {
+	described := make([]string, len(commands))
+	for i, c := range commands {
+		interpreter := c.Interpreter
+		if interpreter == "" {
+			interpreter = "direct"
+		}
+		described[i] = fmt.Sprintf("[%s] %s (%s): %s", c.Module, c.Option, interpreter, c.Command)
+	}
+	sort.Strings(described)
+	return described
+}
+
+// Check returns whether themeID@version with the given content and commands was approved.
+// A version is trusted if its exact content was approved, or if any approved version
+// of the theme runs exactly the same commands.
+func (s *Store) Check(themeID, version, content string, commands []theme.Command) Status {
+	hash := Hash(content)
+	described := DescribeCommands(commands)
+
+	status := Unknown
+	for _, e := range s.Entries {
+		if e.Theme != themeID {
+			continue
+		}
+		if (e.Version == version && e.SHA256 == hash) || slices.Equal(e.Commands, described) {
+			return Trusted
+		}
+		status = Changed
+	}
+	return status
+}
+
+// Approve records themeID@version with content as trusted, replacing an earlier approval of the same content.
+// Approvals of other content of the version are kept, e.g. of the full theme when its variant without
+// [custom] modules is approved, so neither has to be approved again.
+func (s *Store) Approve(themeID, version, content string, commands []theme.Command) {
+	entry := Entry{
+		Theme:      themeID,
+		Version:    version,
+		SHA256:     Hash(content),
+		Commands:   DescribeCommands(commands),
+		ApprovedAt: time.Now().UTC(),
+	}
+
+	for i, e := range s.Entries {
+		if e.Theme == themeID && e.Version == version && e.SHA256 == entry.SHA256 {
+			s.Entries[i] = entry
+			return
+		}
+	}
+	s.Entries = append(s.Entries, entry)
+}
+
+// Revoke removes the approvals of themeID, only of the given version if it is not empty.
+// Returns how many entries were removed.
+func (s *Store) Revoke(themeID, version string) int {
+	kept := s.Entries[:0]
+	removed := 0
+	for _, e := range s.Entries {
+		if e.Theme == themeID && (version == "" || e.Version == version) {
+			removed++
+			continue
+		}
+		kept = append(kept, e)
+	}
+	s.Entries = kept
+	return removed
+}
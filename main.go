@@ -10,13 +10,17 @@ var (
 	version = "dev"
 	commit  = "none"
 	date    = "unknown"
+
+	// updateKey is the minisign public key stellar update verifies releases with
+	updateKey = ""
 )
 
 func main() {
 	// Pass version info to cmd package
 	cmd.SetVersionInfo(version, commit, date)
+	cmd.SetUpdatePublicKey(updateKey)
 
 	if err := cmd.Execute(); err != nil {
-		os.Exit(1)
+		os.Exit(cmd.ExitCode(err))
 	}
 }
\ No newline at end of file